@@ -0,0 +1,84 @@
+package literoute
+
+import (
+	context0 "context"
+	"net/http"
+)
+
+// standaloneMux provides the config of contexts created by HandlerOf when
+// the request does not come through a LiteMux.
+var standaloneMux = New(DefaultConfig)
+
+// WrapMiddleware adapts a net/http middleware, the rest of the chain runs
+// when the handler it wraps is called. Requests and writers replaced by the
+// middleware are seen by the handlers after it, params are kept.
+func WrapMiddleware(mid func(http.Handler) http.Handler) Middleware {
+	return &httpMiddleware{mid: mid}
+}
+
+type httpMiddleware struct {
+	mid func(http.Handler) http.Handler
+}
+
+// Handle runs the middleware alone and reports whether it called the next
+// handler.
+func (m *httpMiddleware) Handle(ctx Context) bool {
+	called := false
+	m.wrap(func(Context) {
+		called = true
+	})(ctx)
+	return called
+}
+
+func (m *httpMiddleware) wrap(next HandleFunc) HandleFunc {
+	handler := m.mid(HandlerOf(next))
+	return func(ctx Context) {
+		handler.ServeHTTP(ctx.ResponseWriter(), withContext(ctx, ctx.Request()))
+	}
+}
+
+// HandlerOf adapts handle to a net/http handler. Inside a LiteMux, e.g. from
+// a wrapped middleware or a mounted handler, the context of the request is
+// reused, otherwise a context with the default config is acquired.
+func HandlerOf(handle HandleFunc) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if ctx, ok := req.Context().Value(liteContextKey).(*context); ok {
+			serveWith(ctx, rw, req, handle)
+			return
+		}
+		standaloneMux.handleContext(rw, req, handle)
+	})
+}
+
+// withContext returns req carrying ctx, so HandlerOf finds it again.
+func withContext(ctx Context, req *http.Request) *http.Request {
+	if c, ok := req.Context().Value(liteContextKey).(Context); ok && c == ctx {
+		return req
+	}
+	return req.WithContext(context0.WithValue(req.Context(), liteContextKey, ctx))
+}
+
+// serveWith runs handle with the request and writer given by a net/http
+// middleware. A replaced writer is flushed before returning to the
+// middleware, the previous request and writer are restored afterwards, also
+// when handle panics so that recovery writes to the outer writer.
+func serveWith(ctx *context, rw http.ResponseWriter, req *http.Request, handle HandleFunc) {
+	request, writer := ctx.request, ctx.writer
+	ctx.request = req
+	replaced := rw != http.ResponseWriter(writer)
+	if replaced {
+		ctx.writer = acquireResponseWriter()
+		ctx.writer.BeginResponse(rw)
+	}
+	defer func() {
+		if replaced {
+			ctx.writer.EndResponse()
+			ctx.writer = writer
+		}
+		ctx.request = request
+	}()
+	handle(ctx)
+	if replaced {
+		ctx.writer.FlushResponse()
+	}
+}
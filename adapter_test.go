@@ -0,0 +1,85 @@
+package literoute
+
+import (
+	context0 "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func TestWrapMiddleware(t *testing.T) {
+	var status int
+	std := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			rec := &statusRecorder{ResponseWriter: rw}
+			rw.Header().Set("X-Std", "1")
+			next.ServeHTTP(rec, req.WithContext(context0.WithValue(req.Context(), "user", "ann")))
+			status = rec.status
+		})
+	}
+
+	mux := Default()
+	mux.AppendMiddleware(WrapMiddleware(std))
+	var outer, inner Context
+	mux.AppendMiddlewareFunc(func(ctx Context, next HandleFunc) {
+		outer = ctx
+		next(ctx)
+	})
+	mux.Get("/users/:id", func(ctx Context) {
+		inner = ctx
+		ctx.StatusCode(http.StatusCreated)
+		_, _ = ctx.WriteString(ctx.Param("id") + " " + ctx.Request().Context().Value("user").(string))
+	})
+
+	rec := serveRequest(mux, http.MethodGet, "/users/7")
+	if rec.Code != http.StatusCreated || rec.Body.String() != "7 ann" || rec.Header().Get("X-Std") != "1" {
+		t.Errorf("got %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	if status != http.StatusCreated {
+		t.Errorf("middleware saw status %d", status)
+	}
+	if outer == nil || outer != inner {
+		t.Errorf("context was not kept across the middleware")
+	}
+
+	blocking := WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			http.Error(rw, "denied", http.StatusForbidden)
+		})
+	})
+	mux = Default()
+	mux.Get("/", writeName("index"), blocking)
+	if rec := serveRequest(mux, http.MethodGet, "/"); rec.Code != http.StatusForbidden || rec.Body.String() != "denied\n" {
+		t.Errorf("blocking middleware got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestHandlerOf(t *testing.T) {
+	handler := HandlerOf(func(ctx Context) {
+		ctx.Succeed(map[string]string{"id": ctx.Param("id")})
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	if rec.Code != DefaultConfig.Status.Succeed || rec.Body.String() != `{"id":""}` {
+		t.Errorf("standalone got %d %q", rec.Code, rec.Body.String())
+	}
+
+	mux := Default()
+	mux.Party("/tenants/:tid").Mount("/api", HandlerOf(func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Param("tid") + " " + ctx.Path())
+	}))
+	if rec := serveRequest(mux, http.MethodGet, "/tenants/acme/api/users"); rec.Body.String() != "acme /users" {
+		t.Errorf("mounted got %q", rec.Body.String())
+	}
+}
@@ -0,0 +1,188 @@
+package literoute
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pharosnet/literoute/schema"
+)
+
+// bindTags are the string sources of Bind, later ones override earlier ones.
+var bindTags = []string{"form", "url", "header", "cookie", "path"}
+
+var (
+	bindDecoders = make(map[string]*schema.Decoder, len(bindTags))
+	bindFields   sync.Map // reflect.Type -> map[string]map[string]bool
+)
+
+func init() {
+	for _, tag := range bindTags {
+		decoder := schema.NewDecoder()
+		decoder.SetAliasTag(tag)
+		decoder.IgnoreUnknownKeys(true)
+		bindDecoders[tag] = decoder
+	}
+}
+
+// Bind fills the struct ptr points to from the request. The body is decoded
+// by the decoder registered for its Content-Type, then fields tagged with
+// form, url, header, cookie and path are set from the posted form, the query,
+// the headers, the cookies and the route params in that order. The errors of
+// all sources are returned as one schema.MultiError, without them the struct
+// is checked by ValidateStruct unless Config.SkipValidation is set.
+func (ctx *context) Bind(ptr interface{}) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("bind: %T is not a pointer to struct", ptr)
+	}
+	fields := taggedFields(v.Elem().Type())
+	errs := schema.MultiError{}
+
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetContentTypeRequested())
+	switch mediaType {
+	case ContentFormHeaderValue, ContentFormMultipartHeaderValue:
+		if names := fields["form"]; len(names) > 0 {
+			ctx.form()
+			bindValues(ptr, "form", nestedValues(ctx.request.PostForm, names), errs)
+		}
+	default:
+		if decoder, has := ctx.Mux().decoders[mediaType]; has {
+			ctx.bindBody(ptr, decoder, errs)
+		}
+	}
+
+	for _, tag := range bindTags[1:] {
+		names := fields[tag]
+		if len(names) == 0 {
+			continue
+		}
+		var src map[string][]string
+		switch tag {
+		case "url":
+			src = nestedValues(ctx.request.URL.Query(), names)
+		case "header":
+			src = make(map[string][]string, len(names))
+			for name := range names {
+				if values := ctx.request.Header[textproto.CanonicalMIMEHeaderKey(name)]; len(values) > 0 {
+					src[name] = values
+				}
+			}
+		case "cookie":
+			src = make(map[string][]string, len(names))
+			for name := range names {
+				if cookie, err := ctx.request.Cookie(name); err == nil {
+					src[name] = []string{cookie.Value}
+				}
+			}
+		case "path":
+			params := ctx.getAllParams()
+			src = make(map[string][]string, len(names))
+			for name := range names {
+				if value, has := params[name]; has {
+					src[name] = []string{value}
+				}
+			}
+		}
+		bindValues(ptr, tag, src, errs)
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return ctx.validate(ptr)
+}
+
+func (ctx *context) bindBody(ptr interface{}, decoder Unmarshaller, errs schema.MultiError) {
+	if ctx.request.Body == nil || ctx.request.Body == http.NoBody {
+		return
+	}
+	data, err := ctx.GetBody()
+	if err != nil {
+		errs["body"] = err
+		return
+	}
+	if len(data) == 0 {
+		return
+	}
+	if bodyDecoder, ok := ptr.(BodyDecoder); ok {
+		err = bodyDecoder.Decode(data)
+	} else {
+		err = decoder.Unmarshal(data, ptr)
+	}
+	if err == nil {
+		return
+	}
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+		errs[typeErr.Field] = err
+		return
+	}
+	errs["body"] = err
+}
+
+func bindValues(ptr interface{}, tag string, src map[string][]string, errs schema.MultiError) {
+	err := bindDecoders[tag].Decode(ptr, src)
+	if multi, ok := err.(schema.MultiError); ok {
+		for key, e := range multi {
+			errs[key] = e
+		}
+	} else if err != nil {
+		errs[tag] = err
+	}
+}
+
+// nestedValues keeps the keys of values naming a field, directly or as the
+// first part of a dotted path.
+func nestedValues(values map[string][]string, names map[string]bool) map[string][]string {
+	src := make(map[string][]string, len(names))
+	for key, v := range values {
+		name := key
+		if i := strings.IndexByte(key, '.'); i >= 0 {
+			name = key[:i]
+		}
+		if names[name] {
+			src[key] = v
+		}
+	}
+	return src
+}
+
+// taggedFields returns for each tag of bindTags the names given to the
+// fields of t, embedded structs included.
+func taggedFields(t reflect.Type) map[string]map[string]bool {
+	if fields, ok := bindFields.Load(t); ok {
+		return fields.(map[string]map[string]bool)
+	}
+	fields := make(map[string]map[string]bool, len(bindTags))
+	collectFields(t, fields)
+	bindFields.Store(t, fields)
+	return fields
+}
+
+func collectFields(t reflect.Type, fields map[string]map[string]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag == "" {
+			collectFields(f.Type, fields)
+			continue
+		}
+		for _, tag := range bindTags {
+			name := f.Tag.Get(tag)
+			if i := strings.IndexByte(name, ','); i >= 0 {
+				name = name[:i]
+			}
+			if name == "" || name == "-" {
+				continue
+			}
+			if fields[tag] == nil {
+				fields[tag] = make(map[string]bool)
+			}
+			fields[tag][name] = true
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pharosnet/literoute/schema"
+)
+
+type bindAddress struct {
+	City string `form:"city"`
+	Zip  string `form:"zip"`
+}
+
+type bindPaging struct {
+	Page int `url:"page"`
+	Size int `url:"size"`
+}
+
+type bindTodo struct {
+	bindPaging
+	ID      int         `path:"id"`
+	Token   string      `header:"X-Token,required"`
+	Session string      `cookie:"session"`
+	Name    string      `json:"name" form:"name"`
+	Tags    []string    `json:"tags" url:"tag"`
+	Done    bool        `json:"done"`
+	Address bindAddress `form:"address"`
+}
+
+func bindRequest(t *testing.T, req *http.Request) (bindTodo, error) {
+	t.Helper()
+	var (
+		todo bindTodo
+		err  error
+	)
+	mux := Default()
+	mux.Handle(http.MethodPost, "/todo/:id", HandlerOf(func(ctx Context) {
+		err = ctx.Bind(&todo)
+	}))
+	mux.ServeHTTP(httptest.NewRecorder(), req)
+	return todo, err
+}
+
+func TestBindJSON(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/todo/7?page=2&tag=a&tag=b",
+		strings.NewReader(`{"id": 9, "name": "milk", "tags": ["x"], "done": true}`))
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("x-token", "secret")
+	req.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	todo, err := bindRequest(t, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.ID != 7 || todo.Page != 2 || todo.Token != "secret" || todo.Session != "s1" ||
+		todo.Name != "milk" || !todo.Done || strings.Join(todo.Tags, ",") != "a,b" {
+		t.Errorf("bound %+v", todo)
+	}
+}
+
+func TestBindForm(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/todo/7",
+		strings.NewReader("name=eggs&address.city=Oslo&address.zip=0150&page=5"))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("X-Token", "secret")
+
+	todo, err := bindRequest(t, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if todo.Name != "eggs" || todo.Address.City != "Oslo" || todo.Address.Zip != "0150" || todo.Page != 0 {
+		t.Errorf("bound %+v", todo)
+	}
+}
+
+func TestBindErrors(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/todo/x?page=two", strings.NewReader(`{"done": "yes"}`))
+	req.Header.Set("Content-Type", "application/json")
+
+	_, err := bindRequest(t, req)
+	multi, ok := err.(schema.MultiError)
+	if !ok {
+		t.Fatalf("got %T %v", err, err)
+	}
+	for _, key := range []string{"id", "page", "X-Token", "done"} {
+		if multi[key] == nil {
+			t.Errorf("no error for %s in %v", key, multi)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"path"
 	"strings"
 	"time"
 )
@@ -20,6 +21,19 @@ func cleanURL(url *string) {
 	}
 }
 
+// cleanPath resolves duplicate slashes, "." and ".." segments of p and keeps
+// its trailing slash.
+func cleanPath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	cleaned := path.Clean("/" + p)
+	if p[len(p)-1] == '/' && cleaned != "/" {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
 func valid(path string) bool {
 	pathLength := len(path)
 	if pathLength > 1 && path[pathLength-1:] == "/" {
@@ -52,6 +52,10 @@ type Context interface {
 
 	Redirect(urlToRedirect string, statusHeader ...int)
 
+	URLFor(name string, pairs ...string) (string, error)
+
+	Values() *Values
+
 	Param(key string) string
 	ParamInt(key string) (int, error)
 	ParamInt32(key string) (int32, error)
@@ -93,6 +97,9 @@ type Context interface {
 	NotFound()
 	Fail(v interface{})
 	Invalid(v interface{})
+	Error(err error)
+	Negotiate(v interface{}) (int, error)
+	Encode(mediaType string, v interface{}) error
 
 	SetMaxRequestBodySize(limitOverBytes int64)
 
@@ -100,11 +107,14 @@ type Context interface {
 
 	UnmarshalBody(outPtr interface{}, unMarshaller Unmarshaller) error
 	ReadJSON(jsonObjectPtr interface{}) error
+	ReadBody(ptr interface{}) error
 
 	ReadForm(formObject interface{}) error
 
 	ReadQuery(ptr interface{}) error
 
+	Bind(ptr interface{}) error
+
 	Write(body []byte) (int, error)
 
 	WriteString(body string) (int, error)
@@ -201,6 +211,9 @@ func acquireContext(mux *LiteMux, w http.ResponseWriter, r *http.Request) Contex
 		ctx = newContext(mux)
 	} else {
 		ctx, _ = v.(Context)
+		if c, ok := ctx.(*context); ok {
+			c.mux = mux
+		}
 	}
 	ctx.BeginRequest(w, r)
 	return ctx
@@ -224,6 +237,7 @@ type context struct {
 	mux     *LiteMux
 	writer  ResponseWriter
 	request *http.Request
+	values  Values
 }
 
 func (ctx *context) String() string {
@@ -236,6 +250,10 @@ func (ctx *context) String() string {
 		ctx.id, ctx.RemoteAddr(), ctx.Method(), ctx.Request().RequestURI)
 }
 
+func (ctx *context) Values() *Values {
+	return &ctx.values
+}
+
 func (ctx *context) Mux() *LiteMux {
 	return ctx.mux
 }
@@ -249,6 +267,7 @@ func (ctx *context) BeginRequest(w http.ResponseWriter, r *http.Request) () {
 func (ctx *context) End() {
 	ctx.writer.FlushResponse()
 	ctx.writer.EndResponse()
+	ctx.values.Reset()
 }
 
 var lastCapturedContextID uint64
@@ -463,20 +482,21 @@ func (ctx *context) Invalid(v interface{}) {
 	}(), v)
 }
 
+func (ctx *context) Error(err error) {
+	handle := ctx.Mux().errorHandler
+	if handle == nil {
+		handle = DefaultErrorHandler
+	}
+	handle(ctx, err)
+}
+
 func (ctx *context) op(status int, v interface{}) {
 	ctx.StatusCode(status)
-	switch ctx.Mux().getConfig().BodyEncoder {
-	case JsonBodyEncode:
-		_, _ = ctx.JSON(v)
-	case XmlBodyEncode:
-		_, _ = ctx.XML(v)
-	default:
-		encoder := ctx.Mux().getExtraBodyEncoder()
-		if encoder == nil {
-			panic("write failed, no body encoder")
-		}
-		encoder.Encode(v)
+	if ctx.Mux().getConfig().Negotiate {
+		_, _ = ctx.Negotiate(v)
+		return
 	}
+	_ = ctx.Encode(ctx.Mux().bodyEncoderType(), v)
 }
 
 func (ctx *context) NotFound() {
@@ -819,6 +839,10 @@ func (ctx *context) Redirect(urlToRedirect string, statusHeader ...int) {
 	http.Redirect(ctx.writer, ctx.request, urlToRedirect, status)
 }
 
+func (ctx *context) URLFor(name string, pairs ...string) (string, error) {
+	return ctx.mux.URL(name, pairs...)
+}
+
 func (ctx *context) SetMaxRequestBodySize(limitOverBytes int64) {
 	ctx.request.Body = http.MaxBytesReader(ctx.writer, ctx.request.Body, limitOverBytes)
 }
@@ -845,7 +869,10 @@ func (ctx *context) UnmarshalBody(outPtr interface{}, unMarshaller Unmarshaller)
 }
 
 func (ctx *context) ReadJSON(outPtr interface{}) error {
-	return ctx.UnmarshalBody(outPtr, UnMarshallerFunc(json.Unmarshal))
+	if err := ctx.UnmarshalBody(outPtr, UnMarshallerFunc(json.Unmarshal)); err != nil {
+		return err
+	}
+	return ctx.validate(outPtr)
 }
 
 func (ctx *context) ReadXML(outPtr interface{}) error {
@@ -855,19 +882,32 @@ func (ctx *context) ReadXML(outPtr interface{}) error {
 func (ctx *context) ReadForm(formObject interface{}) error {
 	values := ctx.FormValues()
 	if len(values) == 0 {
-		return nil
+		return ctx.validate(formObject)
 	}
 
-	return schema.DecodeForm(values, formObject)
+	if err := schema.DecodeForm(values, formObject); err != nil {
+		return err
+	}
+	return ctx.validate(formObject)
 }
 
 func (ctx *context) ReadQuery(ptr interface{}) error {
 	values := ctx.request.URL.Query()
 	if len(values) == 0 {
-		return nil
+		return ctx.validate(ptr)
 	}
 
-	return schema.DecodeQuery(values, ptr)
+	if err := schema.DecodeQuery(values, ptr); err != nil {
+		return err
+	}
+	return ctx.validate(ptr)
+}
+
+func (ctx *context) validate(ptr interface{}) error {
+	if ctx.Mux().config.SkipValidation {
+		return nil
+	}
+	return ValidateStruct(ptr)
 }
 
 func (ctx *context) Write(rawBody []byte) (int, error) {
@@ -1275,8 +1315,16 @@ type BodyDecoder interface {
 	Decode(data []byte) error
 }
 
+// BodyEncoder streams v to w, the Content-Type is already set to the media
+// type it was registered for.
 type BodyEncoder interface {
-	Encode(v interface{}) []byte
+	Encode(w http.ResponseWriter, v interface{}) error
+}
+
+type BodyEncoderFunc func(w http.ResponseWriter, v interface{}) error
+
+func (f BodyEncoderFunc) Encode(w http.ResponseWriter, v interface{}) error {
+	return f(w, v)
 }
 
 type UnMarshallerFunc func(data []byte, outPtr interface{}) error
@@ -17,6 +17,8 @@ const (
 	GzipHeaderValue                 = "gzip"
 	AcceptEncodingHeaderKey         = "Accept-Encoding"
 	VaryHeaderKey                   = "Vary"
+	AcceptHeaderKey                 = "Accept"
+	AllowHeaderKey                  = "Allow"
 	ContentBinaryHeaderValue        = "application/octet-stream"
 	ContentHTMLHeaderValue          = "text/html"
 	ContentJSONHeaderValue          = "application/json"
@@ -55,4 +57,5 @@ var (
 	ErrNotFound           = errors.New("not found")
 	ErrPreconditionFailed = errors.New("precondition failed")
 	ErrGzipNotSupported   = errors.New("client does not support gzip compression")
+	ErrNotAcceptable      = errors.New("no acceptable media type")
 )
@@ -0,0 +1,107 @@
+package literoute
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+func (m *LiteMux) registerBuiltinCodecs() {
+	jsonEncoder := BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		_, err := WriteJSON(w, v, DefaultJSONOptions)
+		return err
+	})
+	xmlEncoder := BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		_, err := WriteXML(w, v, DefaultXMLOptions)
+		return err
+	})
+	m.RegisterBodyEncoder(ContentJSONHeaderValue, jsonEncoder)
+	m.RegisterBodyEncoder(ContentXMLHeaderValue, xmlEncoder)
+	m.RegisterBodyEncoder(ContentXMLUnreadableHeaderValue, xmlEncoder)
+	m.RegisterBodyDecoder(ContentJSONHeaderValue, UnMarshallerFunc(json.Unmarshal))
+	m.RegisterBodyDecoder(ContentXMLHeaderValue, UnMarshallerFunc(xml.Unmarshal))
+	m.RegisterBodyDecoder(ContentXMLUnreadableHeaderValue, UnMarshallerFunc(xml.Unmarshal))
+}
+
+// RegisterBodyEncoder sets the encoder of mediaType, it may be chosen by
+// Config.BodyEncoder and by content negotiation, where encoders are offered
+// in registration order after the one of Config.BodyEncoder.
+func (m *LiteMux) RegisterBodyEncoder(mediaType string, encoder BodyEncoder) {
+	mediaType = strings.ToLower(mediaType)
+	if _, has := m.encoders[mediaType]; !has {
+		if mediaType == m.bodyEncoderType() {
+			m.encoderTypes = append([]string{mediaType}, m.encoderTypes...)
+		} else {
+			m.encoderTypes = append(m.encoderTypes, mediaType)
+		}
+	}
+	m.encoders[mediaType] = encoder
+}
+
+// RegisterBodyDecoder sets the decoder of request bodies of mediaType used
+// by Context.ReadBody and Context.Bind.
+func (m *LiteMux) RegisterBodyDecoder(mediaType string, decoder Unmarshaller) {
+	m.decoders[strings.ToLower(mediaType)] = decoder
+}
+
+func (m *LiteMux) bodyEncoderType() string {
+	if m.config.BodyEncoder == "" {
+		return ContentJSONHeaderValue
+	}
+	return strings.ToLower(m.config.BodyEncoder)
+}
+
+// Encode writes v with the encoder registered for mediaType, the
+// Content-Type is the media type, with a UTF-8 charset for textual ones.
+// When no encoder is registered, or it fails before anything was written,
+// the status is 500 without Content-Type and the error is returned.
+func (ctx *context) Encode(mediaType string, v interface{}) error {
+	mediaType = strings.ToLower(mediaType)
+	encoder, has := ctx.Mux().encoders[mediaType]
+	if !has {
+		ctx.encodeFailed()
+		return fmt.Errorf("literoute: no body encoder for %s", mediaType)
+	}
+	contentType := mediaType
+	if isTextual(mediaType) {
+		contentType += "; charset=utf-8"
+	}
+	ctx.writer.Header().Set(ContentTypeHeaderKey, contentType)
+	if err := encoder.Encode(ctx.writer, v); err != nil {
+		ctx.encodeFailed()
+		return err
+	}
+	return nil
+}
+
+func (ctx *context) encodeFailed() {
+	if ctx.writer.Written() == NoWritten {
+		ctx.writer.Header().Del(ContentTypeHeaderKey)
+		ctx.StatusCode(http.StatusInternalServerError)
+	}
+}
+
+// isTextual reports whether bodies of mediaType are text, i.e. text/*, JSON
+// and XML including their +json and +xml variants.
+func isTextual(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		mediaType == ContentJSONHeaderValue || mediaType == ContentXMLUnreadableHeaderValue ||
+		strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml")
+}
+
+// ReadBody decodes the body with the decoder registered for its
+// Content-Type and validates the result.
+func (ctx *context) ReadBody(ptr interface{}) error {
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetContentTypeRequested())
+	decoder, has := ctx.Mux().decoders[mediaType]
+	if !has {
+		return fmt.Errorf("literoute: no body decoder for %q", mediaType)
+	}
+	if err := ctx.UnmarshalBody(ptr, decoder); err != nil {
+		return err
+	}
+	return ctx.validate(ptr)
+}
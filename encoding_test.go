@@ -0,0 +1,182 @@
+package literoute
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type encodingPoint struct {
+	X int `json:"x" form:"x"`
+	Y int `json:"y" form:"y" validate:"min=1"`
+}
+
+func encodeCSV(w http.ResponseWriter, v interface{}) error {
+	p, ok := v.(encodingPoint)
+	if !ok {
+		return fmt.Errorf("csv: cannot encode %T", v)
+	}
+	_, err := fmt.Fprintf(w, "%d,%d", p.X, p.Y)
+	return err
+}
+
+func decodeCSV(data []byte, ptr interface{}) error {
+	p, ok := ptr.(*encodingPoint)
+	if !ok {
+		return fmt.Errorf("csv: cannot decode into %T", ptr)
+	}
+	_, err := fmt.Sscanf(string(data), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+func TestBodyEncoders(t *testing.T) {
+	config := DefaultConfig
+	config.BodyEncoder = "text/csv"
+	mux := New(config)
+	mux.RegisterBodyEncoder("text/csv", BodyEncoderFunc(encodeCSV))
+	mux.RegisterBodyEncoder("application/vnd.api+json", BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		_, err := WriteJSON(w, map[string]interface{}{"data": v}, DefaultJSONOptions)
+		return err
+	}))
+	mux.RegisterBodyEncoder("application/msgpack", BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		_, err := w.Write([]byte{0x81})
+		return err
+	}))
+	mux.Get("/point", func(ctx Context) {
+		ctx.Succeed(encodingPoint{X: 1, Y: 2})
+	})
+	mux.Get("/json", func(ctx Context) {
+		if err := ctx.Encode(ContentJSONHeaderValue, encodingPoint{X: 1, Y: 2}); err != nil {
+			t.Error(err)
+		}
+	})
+	mux.Get("/negotiate", func(ctx Context) {
+		if _, err := ctx.Negotiate(encodingPoint{X: 1, Y: 2}); err != nil {
+			t.Error(err)
+		}
+	})
+
+	rec := serveRequest(mux, http.MethodGet, "/point")
+	if rec.Header().Get("Content-Type") != "text/csv; charset=utf-8" || rec.Body.String() != "1,2" {
+		t.Errorf("default encoder got %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+	rec = serveRequest(mux, http.MethodGet, "/json")
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), ContentJSONHeaderValue) ||
+		strings.TrimSpace(rec.Body.String()) != `{"x":1,"y":2}` {
+		t.Errorf("json encoder got %q %q", rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	accepts := map[string]string{
+		"":                                    "1,2",
+		"*/*":                                 "1,2",
+		"application/json":                    `{"x":1,"y":2}`,
+		"text/xml":                            `<encodingPoint><X>1</X><Y>2</Y></encodingPoint>`,
+		"application/json, text/csv;q=0.5":    `{"x":1,"y":2}`,
+		"application/json;q=0.5, text/csv":    "1,2",
+		"application/xml;q=0.9, text/*;q=0.8": `<encodingPoint><X>1</X><Y>2</Y></encodingPoint>`,
+		"application/vnd.api+json":            `{"data":{"x":1,"y":2}}`,
+		"application/msgpack":                 "\x81",
+	}
+	for accept, want := range accepts {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/negotiate", nil)
+		req.Header.Set("Accept", accept)
+		mux.ServeHTTP(rec, req)
+		if body := strings.TrimSpace(rec.Body.String()); body != want {
+			t.Errorf("Accept %q got %q", accept, body)
+		}
+		if accept == "application/vnd.api+json" && rec.Header().Get("Content-Type") != "application/vnd.api+json; charset=utf-8" {
+			t.Errorf("vendor media type sent as %q", rec.Header().Get("Content-Type"))
+		}
+		if accept == "application/msgpack" && rec.Header().Get("Content-Type") != "application/msgpack" {
+			t.Errorf("binary media type sent as %q", rec.Header().Get("Content-Type"))
+		}
+	}
+}
+
+func TestBodyEncoderErrors(t *testing.T) {
+	failure := errors.New("encode failed")
+	mux := New(DefaultConfig)
+	mux.RegisterBodyEncoder("text/csv", BodyEncoderFunc(encodeCSV))
+	mux.RegisterBodyEncoder("application/broken", BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		return failure
+	}))
+	var got []error
+	mux.Get("/:type/:sub", func(ctx Context) {
+		got = append(got, ctx.Encode(ctx.Param("type")+"/"+ctx.Param("sub"), ctx.Param("sub")))
+	})
+
+	rec := serveRequest(mux, http.MethodGet, "/application/broken")
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "" || got[0] != failure {
+		t.Errorf("broken encoder got %d %q %v", rec.Code, rec.Header().Get("Content-Type"), got[0])
+	}
+	rec = serveRequest(mux, http.MethodGet, "/text/csv")
+	if rec.Code != http.StatusInternalServerError || got[1] == nil {
+		t.Errorf("csv encoder got %d %v", rec.Code, got[1])
+	}
+	rec = serveRequest(mux, http.MethodGet, "/text/unknown")
+	if rec.Code != http.StatusInternalServerError || got[2] == nil || rec.Body.Len() != 0 {
+		t.Errorf("unknown encoder got %d %v %q", rec.Code, got[2], rec.Body.String())
+	}
+	rec = serveRequest(mux, http.MethodGet, "/application/json")
+	if rec.Code != http.StatusOK || strings.TrimSpace(rec.Body.String()) != `"json"` || got[3] != nil {
+		t.Errorf("json encoder got %d %q %v", rec.Code, rec.Body.String(), got[3])
+	}
+}
+
+func TestMissingBodyEncoder(t *testing.T) {
+	mux := New(Config{BodyEncoder: "application/msgpack"})
+	mux.Get("/", func(ctx Context) {
+		ctx.Succeed("ok")
+	})
+	rec := serveRequest(mux, http.MethodGet, "/")
+	if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "" || rec.Body.Len() != 0 {
+		t.Errorf("missing encoder got %d %q %q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+}
+
+func TestBodyDecoders(t *testing.T) {
+	mux := New(DefaultConfig)
+	mux.RegisterBodyDecoder("text/csv", UnMarshallerFunc(decodeCSV))
+	mux.Post("/read", HandleErr(func(ctx Context) error {
+		var p encodingPoint
+		if err := ctx.ReadBody(&p); err != nil {
+			return err
+		}
+		_, err := ctx.JSON(p)
+		return err
+	}))
+	mux.Post("/bind", HandleErr(func(ctx Context) error {
+		var p encodingPoint
+		if err := ctx.Bind(&p); err != nil {
+			return err
+		}
+		_, err := ctx.JSON(p)
+		return err
+	}))
+
+	cases := []struct {
+		path, contentType, body string
+		code                    int
+		want                    string
+	}{
+		{"/read", "text/csv", "3,4", http.StatusOK, `{"x":3,"y":4}`},
+		{"/read", "application/json", `{"x":3,"y":4}`, http.StatusOK, `{"x":3,"y":4}`},
+		{"/read", "text/csv", "3,0", DefaultConfig.Status.InvalidRequest, `{"y":"must be at least 1"}`},
+		{"/read", "text/plain", "3,4", DefaultConfig.Status.Fail, `{"error":"Internal Server Error"}`},
+		{"/bind", "text/csv; charset=utf-8", "5,6", http.StatusOK, `{"x":5,"y":6}`},
+		{"/bind", ContentFormHeaderValue, "x=5&y=6", http.StatusOK, `{"x":5,"y":6}`},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(c.body))
+		req.Header.Set("Content-Type", c.contentType)
+		mux.ServeHTTP(rec, req)
+		if rec.Code != c.code || strings.TrimSpace(rec.Body.String()) != c.want {
+			t.Errorf("%s %s got %d %s", c.path, c.contentType, rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package literoute
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/pharosnet/literoute/schema"
+)
+
+// HandleErrFunc is a handler returning an error, register it with HandleErr
+// so that the error goes to the error handler of the mux.
+type HandleErrFunc func(ctx Context) error
+
+// HandleErr adapts handle to a HandleFunc passing its error to ctx.Error.
+func HandleErr(handle HandleErrFunc) HandleFunc {
+	return func(ctx Context) {
+		if err := handle(ctx); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// MiddlewareErrFunc is a MiddlewareFunc returning an error, the error goes
+// to the error handler of the mux like the errors of handlers.
+type MiddlewareErrFunc func(ctx Context, next HandleFunc) error
+
+// Handle reports whether next was called, an error returned before that
+// stops the chain.
+func (f MiddlewareErrFunc) Handle(ctx Context) bool {
+	called := false
+	if err := f(ctx, func(Context) {
+		called = true
+	}); err != nil {
+		ctx.Error(err)
+		return false
+	}
+	return called
+}
+
+func (f MiddlewareErrFunc) wrap(next HandleFunc) HandleFunc {
+	return func(ctx Context) {
+		if err := f(ctx, next); err != nil {
+			ctx.Error(err)
+		}
+	}
+}
+
+// ErrorHandleFunc writes the response for an error returned by a handler or
+// a middleware.
+type ErrorHandleFunc func(ctx Context, err error)
+
+// HTTPError is an error answered with Status, Message is the body written by
+// DefaultErrorHandler, the error text is used when it is empty.
+type HTTPError struct {
+	Status  int
+	Message string
+	Err     error
+}
+
+func NewHTTPError(status int, err error) *HTTPError {
+	return &HTTPError{Status: status, Err: err}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Message != "" {
+		return e.Message
+	}
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return http.StatusText(e.Status)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultErrorHandler answers ErrNotFound with Status.NotFound, ParamError,
+// ValidationErrors and the errors of schema with Status.InvalidRequest and a
+// map from field to message, an HTTPError with its status, and other errors with Status.Fail
+// without revealing them.
+func DefaultErrorHandler(ctx Context, err error) {
+	var (
+		httpErr  *HTTPError
+		paramErr ParamError
+		multi    schema.MultiError
+		invalid  ValidationErrors
+	)
+	switch {
+	case errors.As(err, &httpErr):
+		ctx.StatusCode(httpErr.Status)
+		writeError(ctx, httpErr.Error())
+	case errors.Is(err, ErrNotFound):
+		ctx.NotFound()
+		writeError(ctx, ErrNotFound.Error())
+	case errors.Is(err, ErrPreconditionFailed):
+		ctx.StatusCode(http.StatusPreconditionFailed)
+		writeError(ctx, ErrPreconditionFailed.Error())
+	case errors.As(err, &paramErr):
+		ctx.Invalid(paramErr)
+	case errors.As(err, &invalid):
+		ctx.Invalid(invalid)
+	case errors.As(err, &multi):
+		fields := make(map[string]string, len(multi))
+		for key, e := range multi {
+			fields[key] = e.Error()
+		}
+		ctx.Invalid(fields)
+	case isSchemaError(err):
+		ctx.Invalid(map[string]string{"error": err.Error()})
+	default:
+		ctx.Fail(map[string]string{"error": http.StatusText(http.StatusInternalServerError)})
+	}
+}
+
+func isSchemaError(err error) bool {
+	var (
+		conversion schema.ConversionError
+		unknown    schema.UnknownKeyError
+		empty      schema.EmptyFieldError
+	)
+	return errors.As(err, &conversion) || errors.As(err, &unknown) || errors.As(err, &empty)
+}
+
+// writeError writes the body of an error keeping the status already set.
+func writeError(ctx Context, message string) {
+	body := map[string]string{"error": message}
+	if c, ok := ctx.(*context); ok {
+		c.op(ctx.GetStatusCode(), body)
+		return
+	}
+	_, _ = ctx.JSON(body)
+}
+
+// ErrorHandler sets the handler of the errors passed to Context.Error,
+// DefaultErrorHandler is used when it is not set.
+func (m *LiteMux) ErrorHandler(handle ErrorHandleFunc) {
+	m.errorHandler = handle
+}
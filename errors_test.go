@@ -0,0 +1,63 @@
+package literoute
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/pharosnet/literoute/schema"
+)
+
+func TestDefaultErrorHandler(t *testing.T) {
+	cases := []struct {
+		err  error
+		code int
+		body string
+	}{
+		{fmt.Errorf("todo 7: %w", ErrNotFound), DefaultConfig.Status.NotFound, `{"error":"not found"}`},
+		{schema.MultiError{"age": errors.New("not a number")}, DefaultConfig.Status.InvalidRequest, `{"age":"not a number"}`},
+		{ParamError{Param: "id", Rule: "Int", Message: "must be an integer"}, DefaultConfig.Status.InvalidRequest,
+			`{"param":"id","rule":"Int","message":"must be an integer"}`},
+		{&HTTPError{Status: http.StatusConflict, Message: "taken"}, http.StatusConflict, `{"error":"taken"}`},
+		{errors.New("db password is hunter2"), DefaultConfig.Status.Fail, `{"error":"Internal Server Error"}`},
+	}
+	for _, c := range cases {
+		mux := Default()
+		err := c.err
+		mux.Get("/", HandleErr(func(ctx Context) error {
+			return err
+		}))
+		rec := serveRequest(mux, http.MethodGet, "/")
+		if rec.Code != c.code || rec.Body.String() != c.body {
+			t.Errorf("%v got %d %s, want %d %s", c.err, rec.Code, rec.Body.String(), c.code, c.body)
+		}
+	}
+}
+
+func TestErrorHandler(t *testing.T) {
+	mux := Default()
+	var handled []error
+	mux.ErrorHandler(func(ctx Context, err error) {
+		handled = append(handled, err)
+		ctx.StatusCode(http.StatusTeapot)
+	})
+	denied := errors.New("denied")
+	mux.Get("/ok", HandleErr(func(ctx Context) error {
+		_, err := ctx.WriteString("ok")
+		return err
+	}))
+	mux.Get("/private", writeName("private"), MiddlewareErrFunc(func(ctx Context, next HandleFunc) error {
+		return denied
+	}))
+
+	if rec := serveRequest(mux, http.MethodGet, "/ok"); rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+		t.Errorf("GET /ok got %d %q", rec.Code, rec.Body.String())
+	}
+	if rec := serveRequest(mux, http.MethodGet, "/private"); rec.Code != http.StatusTeapot || rec.Body.Len() != 0 {
+		t.Errorf("GET /private got %d %q", rec.Code, rec.Body.String())
+	}
+	if len(handled) != 1 || handled[0] != denied {
+		t.Errorf("handled %v", handled)
+	}
+}
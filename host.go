@@ -0,0 +1,118 @@
+package literoute
+
+import (
+	"strings"
+)
+
+// hostRoutes holds the trees of the routes registered for a host pattern
+// such as "api.example.com" or "{tenant}.example.com", a {name} label
+// captures one label of the host as a param.
+type hostRoutes struct {
+	pattern  string
+	labels   []string
+	captures int
+	trees    map[string]*node
+}
+
+func newHostRoutes(pattern string) *hostRoutes {
+	pattern = strings.ToLower(stripPort(pattern))
+	h := &hostRoutes{
+		pattern: pattern,
+		labels:  strings.Split(pattern, "."),
+		trees:   make(map[string]*node),
+	}
+	for _, label := range h.labels {
+		if isHostCapture(label) {
+			h.captures++
+		} else if strings.ContainsAny(label, "{}") {
+			panic("literoute: invalid host pattern " + pattern)
+		}
+	}
+	return h
+}
+
+// match reports whether host matches the pattern and pushes the captured
+// labels to lr.
+func (h *hostRoutes) match(host string, lr *lookupResult) bool {
+	size := lr.size
+	for i, label := range h.labels {
+		end := strings.IndexByte(host, '.')
+		if i == len(h.labels)-1 {
+			if end >= 0 {
+				lr.size = size
+				return false
+			}
+			end = len(host)
+		} else if end < 0 {
+			lr.size = size
+			return false
+		}
+		if isHostCapture(label) {
+			if end == 0 {
+				lr.size = size
+				return false
+			}
+			lr.push(label[1:len(label)-1], host[:end])
+		} else if !strings.EqualFold(label, host[:end]) {
+			lr.size = size
+			return false
+		}
+		if end < len(host) {
+			host = host[end+1:]
+		}
+	}
+	return true
+}
+
+// params returns the names of the captured labels in host order.
+func (h *hostRoutes) params() []string {
+	var names []string
+	for _, label := range h.labels {
+		if isHostCapture(label) {
+			names = append(names, label[1:len(label)-1])
+		}
+	}
+	return names
+}
+
+func isHostCapture(label string) bool {
+	return len(label) > 2 && label[0] == '{' && label[len(label)-1] == '}'
+}
+
+func stripPort(host string) string {
+	if i := strings.LastIndexByte(host, ':'); i >= 0 && i > strings.LastIndexByte(host, ']') {
+		return host[:i]
+	}
+	return host
+}
+
+// Host returns a router whose routes only match requests for the given host,
+// the port of the request is ignored. Labels written as {name} are exposed
+// through Context.Param. Routes of a host are tried before the routes
+// registered without one.
+func (m *LiteMux) Host(pattern string) *Router {
+	host := newHostRoutes(pattern)
+	found := false
+	for _, h := range m.hosts {
+		if h.pattern == host.pattern {
+			host, found = h, true
+			break
+		}
+	}
+	if !found {
+		i := len(m.hosts)
+		if host.captures == 0 {
+			for i > 0 && m.hosts[i-1].captures > 0 {
+				i--
+			}
+		}
+		m.hosts = append(m.hosts, nil)
+		copy(m.hosts[i+1:], m.hosts[i:])
+		m.hosts[i] = host
+	}
+	router := newRouter("", m)
+	router.parent = m.rootRouter
+	router.host = host
+	m.routers = append(m.routers, router)
+	return router
+}
@@ -3,3 +3,53 @@ package literoute
 type Middleware interface {
 	Handle(ctx Context) bool
 }
+
+// MiddlewareFunc wraps the rest of the chain, the handler runs when next is
+// called, so code after next sees the final response.
+//
+//	func Latency(ctx Context, next HandleFunc) {
+//		begin := time.Now()
+//		next(ctx)
+//		log.Println(ctx.Path(), ctx.ResponseWriter().StatusCode(), time.Since(begin))
+//	}
+type MiddlewareFunc func(ctx Context, next HandleFunc)
+
+// Handle runs the middleware with a next that does nothing and reports
+// whether next was called, so a MiddlewareFunc can be used as a Middleware.
+func (f MiddlewareFunc) Handle(ctx Context) bool {
+	called := false
+	f(ctx, func(Context) {
+		called = true
+	})
+	return called
+}
+
+func (f MiddlewareFunc) wrap(next HandleFunc) HandleFunc {
+	return func(ctx Context) {
+		f(ctx, next)
+	}
+}
+
+type middlewareWrapper interface {
+	wrap(next HandleFunc) HandleFunc
+}
+
+func wrapMiddleware(mid Middleware, next HandleFunc) HandleFunc {
+	if w, ok := mid.(middlewareWrapper); ok {
+		return w.wrap(next)
+	}
+	return func(ctx Context) {
+		if mid.Handle(ctx) {
+			next(ctx)
+		}
+	}
+}
+
+func compose(handle HandleFunc, midList ...[]Middleware) HandleFunc {
+	for i := len(midList) - 1; i >= 0; i-- {
+		for j := len(midList[i]) - 1; j >= 0; j-- {
+			handle = wrapMiddleware(midList[i][j], handle)
+		}
+	}
+	return handle
+}
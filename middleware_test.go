@@ -0,0 +1,90 @@
+package literoute
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type traceMid struct {
+	name  string
+	allow bool
+}
+
+func (m *traceMid) Handle(ctx Context) bool {
+	_, _ = ctx.WriteString(m.name + ">")
+	return m.allow
+}
+
+func TestScopedMiddleware(t *testing.T) {
+	mux := Default()
+	mux.AppendMiddleware(&traceMid{name: "global", allow: true})
+	mux.Get("/healthz", writeName("health"))
+
+	api := mux.Party("/api")
+	api.Use(&traceMid{name: "auth", allow: true})
+	api.Get("/users", writeName("users"), &traceMid{name: "route", allow: true})
+	api.Get("/admin", writeName("admin"), &traceMid{name: "deny", allow: false})
+	api.Get("/assets/", writeName("assets"), &traceMid{name: "deny", allow: false})
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/healthz", "global>health"},
+		{"/api/users", "global>auth>route>users"},
+		{"/api/admin", "global>auth>deny>"},
+		{"/api/assets/app.js", "global>auth>deny>"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, http.MethodGet, c.path)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("GET %s wrote %q, want %q", c.path, got, c.want)
+		}
+	}
+
+	api.Use(&traceMid{name: "late", allow: true})
+	if got := serveRequest(mux, http.MethodGet, "/api/users").Body.String(); !strings.Contains(got, "auth>late>route>") {
+		t.Errorf("middleware added after registration not applied, got %q", got)
+	}
+}
+
+func TestMiddlewareFunc(t *testing.T) {
+	mux := Default()
+	var status int
+	mux.AppendMiddlewareFunc(func(ctx Context, next HandleFunc) {
+		_, _ = ctx.WriteString("before>")
+		next(ctx)
+		status = ctx.ResponseWriter().StatusCode()
+	})
+	api := mux.Party("/api")
+	api.Use(&traceMid{name: "veto", allow: true})
+	api.UseFunc(func(ctx Context, next HandleFunc) {
+		_, _ = ctx.WriteString("inner>")
+		next(ctx)
+		_, _ = ctx.WriteString("<inner")
+	})
+	api.Get("/users", func(ctx Context) {
+		ctx.StatusCode(http.StatusAccepted)
+		_, _ = ctx.WriteString("users")
+	})
+	api.Get("/stop", writeName("stop"), MiddlewareFunc(func(ctx Context, next HandleFunc) {
+		ctx.StatusCode(http.StatusForbidden)
+	}))
+
+	rec := serveRequest(mux, http.MethodGet, "/api/users")
+	if got, want := rec.Body.String(), "before>veto>inner>users<inner"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("middleware saw status %d after next", status)
+	}
+
+	rec = serveRequest(mux, http.MethodGet, "/api/stop")
+	if got, want := rec.Body.String(), "before>veto>inner><inner"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if status != http.StatusForbidden {
+		t.Errorf("middleware saw status %d after next", status)
+	}
+}
@@ -1,24 +1,33 @@
 package literoute
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
 )
 
 func New(config Config) (mux *LiteMux) {
 	mux = &LiteMux{
-		config:           config,
-		routes:           make(map[string][]*route),
-		validators:       make(map[string]Validator),
-		middlewareList:   make([]Middleware, 0, 1),
-		middlewareNum:    0,
-		extraBodyEncoder: nil,
+		config:          config,
+		routes:          make(map[string][]*Route),
+		trees:           make(map[string]*node),
+		named:           make(map[string]*Route),
+		shapes:          make(map[string]*Route),
+		validators:      make(map[string]Validator),
+		paramValidators: make(map[string]ParamValidator),
+		middlewareList:  make([]Middleware, 0, 1),
+		encoders:        make(map[string]BodyEncoder),
+		decoders:        make(map[string]Unmarshaller),
 	}
 	mux.rootRouter = newRouter("/", mux)
+	mux.registerBuiltinCodecs()
 	return
 }
 
 func Default() (mux *LiteMux) {
-	return New(DefaultConfig)
+	mux = New(DefaultConfig)
+	mux.RegisterBuiltinValidators()
+	return
 }
 
 var (
@@ -27,15 +36,50 @@ var (
 	methods  = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodHead, http.MethodPatch, http.MethodOptions, http.MethodConnect, http.MethodTrace}
 )
 
+// JsonBodyEncode and XmlBodyEncode name the built-in encoders for
+// Config.BodyEncoder, any media type with a registered encoder may be used.
+const (
+	JsonBodyEncode = ContentJSONHeaderValue
+	XmlBodyEncode  = ContentXMLHeaderValue
+)
+
 const (
-	JsonBodyEncode = iota
-	XmlBodyEncode
+	// TrailingSlashRedirect redirects to the path without the trailing slash
+	// when it matches a route, 302 for GET and HEAD and 308 otherwise.
+	TrailingSlashRedirect = iota
+	// TrailingSlashStrict treats a trailing slash as part of the path.
+	TrailingSlashStrict
+	// TrailingSlashMatch serves the route matching the path without the
+	// trailing slash.
+	TrailingSlashMatch
 )
 
 type Config struct {
-	BodyEncoder   int
+	// BodyEncoder is the media type of the bodies written by Succeed, Fail
+	// and Invalid, JSON when empty.
+	BodyEncoder   string
 	Status        CustomizeStatus
 	PostMaxMemory int64
+	// Negotiate makes Succeed, Fail and Invalid write the body with the
+	// encoder the Accept header prefers, see Context.Negotiate.
+	Negotiate bool
+	// AllowOverride lets a route replace an earlier one matching the same
+	// requests instead of panicking at registration.
+	AllowOverride bool
+	// TrailingSlash is one of TrailingSlashRedirect, TrailingSlashStrict
+	// and TrailingSlashMatch.
+	TrailingSlash int
+	// CleanPath resolves duplicate slashes, "." and ".." segments of paths
+	// that match no route. CaseInsensitive matches the static parts of the
+	// routes ignoring case. The fixed path is redirected to unless
+	// TrailingSlash is TrailingSlashStrict or TrailingSlashMatch, then it is
+	// served directly.
+	CleanPath       bool
+	CaseInsensitive bool
+	// SkipValidation stops ReadJSON, ReadForm, ReadQuery, ReadBody and Bind
+	// from checking the struct with ValidateStruct, e.g. when its validate
+	// tags are meant for another validator.
+	SkipValidation bool
 }
 
 type CustomizeStatus struct {
@@ -59,17 +103,39 @@ var DefaultConfig = Config{
 type LiteMux struct {
 	config           Config
 	rootRouter       *Router
-	routes           map[string][]*route
+	routes           map[string][]*Route
+	trees            map[string]*node
+	hosts            []*hostRoutes
+	named            map[string]*Route
+	shapes           map[string]*Route
+	routers          []*Router
 	notFound         HandleFunc
+	methodNotAllowed HandleFunc
+	errorHandler     ErrorHandleFunc
+	panicReporter    PanicReportFunc
 	validators       map[string]Validator
-	middlewareNum    int
+	paramValidators  map[string]ParamValidator
 	middlewareList   []Middleware
-	extraBodyEncoder BodyEncoder
+	encoders         map[string]BodyEncoder
+	encoderTypes     []string
+	decoders         map[string]Unmarshaller
 }
 
 func (m *LiteMux) AppendMiddleware(mid Middleware) {
 	m.middlewareList = append(m.middlewareList, mid)
-	m.middlewareNum = len(m.middlewareList)
+	m.compose()
+}
+
+func (m *LiteMux) AppendMiddlewareFunc(mid MiddlewareFunc) {
+	m.AppendMiddleware(mid)
+}
+
+func (m *LiteMux) compose() {
+	for _, routes := range m.routes {
+		for _, r := range routes {
+			r.compose()
+		}
+	}
 }
 
 func (m *LiteMux) RegisterValidator(name string, validator Validator) {
@@ -79,45 +145,186 @@ func (m *LiteMux) RegisterValidator(name string, validator Validator) {
 	m.validators[name] = validator
 }
 
-func (m *LiteMux) RegisterBodyEncode(encode BodyEncoder) {
-	m.extraBodyEncoder = encode
-}
-
-func (m *LiteMux) getExtraBodyEncoder() BodyEncoder {
-	return m.extraBodyEncoder
+func (m *LiteMux) RegisterParamValidator(name string, validator ParamValidator) {
+	if m.paramValidators == nil {
+		m.paramValidators = make(map[string]ParamValidator)
+	}
+	m.paramValidators[name] = validator
 }
 
 func (m *LiteMux) getConfig() Config {
 	return m.config
 }
 
-func (m *LiteMux) parse(rw http.ResponseWriter, req *http.Request) (bool, int) {
-	for _, r := range m.routes[req.Method] {
-		ok, match := r.parse(rw, req)
-		if ok {
-			return true, match
-		}
+func (m *LiteMux) addRoute(method string, r *Route) {
+	trees, host := m.trees, ""
+	if r.router.host != nil {
+		trees, host = r.router.host.trees, r.router.host.pattern
+	}
+	m.claim(method+" "+host+r.shape(), r)
+	m.routes[method] = append(m.routes[method], r)
+	root := trees[method]
+	if root == nil {
+		root = newTree()
+		trees[method] = root
 	}
+	root.insert(r.path, r)
+}
 
-	if req.Method == http.MethodHead {
-		for _, r := range m.routes[http.MethodGet] {
-			ok, match := r.parse(rw, req)
-			if ok {
-				return true, match
+// claim reserves key for r. A key already held by another route means both
+// would match the same requests, which panics unless Config.AllowOverride
+// is set, then the earlier route is removed.
+func (m *LiteMux) claim(key string, r *Route) {
+	old, has := m.shapes[key]
+	if has {
+		if !m.config.AllowOverride {
+			if old.path == r.path {
+				panic(fmt.Sprintf("literoute: route %s %s is registered twice", r.method, r.path))
 			}
+			panic(fmt.Sprintf("literoute: route %s %s conflicts with %s %s, both match the same requests",
+				r.method, r.path, old.method, old.path))
 		}
+		m.removeRoute(old)
 	}
+	m.shapes[key] = r
+}
 
+func (m *LiteMux) removeRoute(r *Route) {
+	key := r.method
+	if r.node == nil {
+		key = static
+	} else {
+		r.node.route = nil
+		r.node = nil
+	}
+	routes := m.routes[key]
+	for i, other := range routes {
+		if other == r {
+			m.routes[key] = append(routes[:i:i], routes[i+1:]...)
+			break
+		}
+	}
+	if r.name != "" && m.named[r.name] == r {
+		delete(m.named, r.name)
+	}
+}
+
+// lookup tries the trees of the hosts matching host first, then the trees
+// of the routes registered without a host.
+func (m *LiteMux) lookup(host string, method string, path string, lr *lookupResult) *Route {
+	lr.reset()
+	for _, h := range m.hosts {
+		root := h.trees[method]
+		if root == nil {
+			continue
+		}
+		lr.size = 0
+		if !h.match(host, lr) {
+			continue
+		}
+		lr.base = lr.size
+		if r := root.lookup(path, lr); r != nil {
+			return r
+		}
+	}
+	root := m.trees[method]
+	if root == nil {
+		return nil
+	}
+	lr.size, lr.base = 0, 0
+	return root.lookup(path, lr)
+}
+
+func (m *LiteMux) parse(rw http.ResponseWriter, req *http.Request) (bool, int) {
+	return m.parsePath(rw, req, req.URL.EscapedPath())
+}
+
+func (m *LiteMux) parsePath(rw http.ResponseWriter, req *http.Request, path string) (bool, int) {
+	var lr lookupResult
+	host := stripPort(GetHost(req))
+	r := m.lookup(host, req.Method, path, &lr)
+	if r == nil && lr.failed == nil && req.Method == http.MethodHead {
+		r = m.lookup(host, http.MethodGet, path, &lr)
+	}
+
+	if r != nil {
+		r.serve(rw, req, &lr)
+		return true, matchOk
+	}
+	if lr.failed != nil {
+		m.handleContext(rw, req, lr.failed.OnFail)
+		return true, matchFail
+	}
 	return false, matchNon
 }
 
+// resolves reports whether a route of method, or GET for HEAD, matches path.
+func (m *LiteMux) resolves(host string, method string, path string) bool {
+	var lr lookupResult
+	if r := m.lookup(host, method, path, &lr); r != nil || lr.failed != nil {
+		return true
+	}
+	if method == http.MethodHead {
+		return m.resolves(host, http.MethodGet, path)
+	}
+	return false
+}
+
+// fixCase returns path with its static parts spelled as in the routes of
+// method.
+func (m *LiteMux) fixCase(host string, method string, path string) (string, bool) {
+	var lr lookupResult
+	for _, h := range m.hosts {
+		root := h.trees[method]
+		if root == nil {
+			continue
+		}
+		lr.reset()
+		if !h.match(host, &lr) {
+			continue
+		}
+		if fixed, ok := root.fixCase(path, make([]byte, 0, len(path))); ok {
+			return string(fixed), true
+		}
+	}
+	if root := m.trees[method]; root != nil {
+		if fixed, ok := root.fixCase(path, make([]byte, 0, len(path))); ok {
+			return string(fixed), true
+		}
+	}
+	if method == http.MethodHead {
+		return m.fixCase(host, http.MethodGet, path)
+	}
+	return "", false
+}
+
+// fixPath applies the path policy of the config to path, it returns the
+// path of a matching route or path itself.
+func (m *LiteMux) fixPath(host string, method string, path string) string {
+	fixed := path
+	if m.config.CleanPath {
+		fixed = cleanPath(fixed)
+	}
+	if m.config.TrailingSlash != TrailingSlashStrict {
+		cleanURL(&fixed)
+	}
+	if fixed != path && m.resolves(host, method, fixed) {
+		return fixed
+	}
+	if m.config.CaseInsensitive {
+		if p, ok := m.fixCase(host, method, fixed); ok && m.resolves(host, method, p) {
+			return p
+		}
+	}
+	return path
+}
+
 func (m *LiteMux) staticRoute(rw http.ResponseWriter, req *http.Request) bool {
+	host := stripPort(GetHost(req))
 	for _, s := range m.routes[static] {
-		if len(req.URL.Path) >= s.Size {
-			if req.URL.Path[:s.Size] == s.Path {
-				ctx := acquireContext(m, rw, req)
-				s.Handle(ctx)
-				releaseContext(ctx)
+		if s.method == req.Method || req.Method == http.MethodHead && s.method == http.MethodGet {
+			if s.matchStatic(host, req.URL.Path) {
+				s.handle(rw, req)
 				return true
 			}
 		}
@@ -126,52 +333,172 @@ func (m *LiteMux) staticRoute(rw http.ResponseWriter, req *http.Request) bool {
 }
 
 func (m *LiteMux) validate(rw http.ResponseWriter, req *http.Request) (bool, int) {
-	pathLength := len(req.URL.Path)
-	if pathLength > 1 && req.URL.Path[pathLength-1:] == "/" {
-		cleanURL(&req.URL.Path)
-		rw.Header().Set(location, req.URL.String())
+	path := req.URL.EscapedPath()
+	fixed := m.fixPath(stripPort(GetHost(req)), req.Method, path)
+	if fixed == path {
+		return false, matchNon
+	}
+	if m.config.TrailingSlash != TrailingSlashRedirect {
+		return m.parsePath(rw, withPath(req, fixed), fixed)
+	}
+	if req.URL.RawQuery != "" {
+		fixed += "?" + req.URL.RawQuery
+	}
+	rw.Header().Set(location, fixed)
+	if req.Method == http.MethodGet || req.Method == http.MethodHead {
 		rw.WriteHeader(http.StatusFound)
-		return true, matchOk
+	} else {
+		rw.WriteHeader(http.StatusPermanentRedirect)
 	}
-	return m.parse(rw, req)
+	return true, matchOk
 }
 
-func (m *LiteMux) otherMethods(rw http.ResponseWriter, req *http.Request) bool {
-	for _, method := range methods {
-		if method != req.Method {
-			for _, r := range m.routes[method] {
-				ok := r.exists(rw, req)
-				if ok {
-					rw.WriteHeader(http.StatusMethodNotAllowed)
-					return true
-				}
-			}
+func (m *LiteMux) staticAllows(host string, method string, path string) bool {
+	for _, s := range m.routes[static] {
+		if s.method == method && s.matchStatic(host, path) {
+			return true
 		}
 	}
 	return false
 }
 
-func (m *LiteMux) handleNotFound(rw http.ResponseWriter, req *http.Request) {
-	if m.notFound != nil {
-		ctx := acquireContext(m, rw, req)
-		m.notFound(ctx)
-		releaseContext(ctx)
-	} else {
-		http.NotFound(rw, req)
+// allowed returns the methods registered for path, HEAD is implied by GET
+// and OPTIONS is always answered.
+func (m *LiteMux) allowed(host string, path string) []string {
+	var (
+		lr    lookupResult
+		allow []string
+	)
+	hasGet := false
+	for _, method := range methods {
+		if method == http.MethodOptions {
+			continue
+		}
+		if method == http.MethodHead && hasGet {
+			allow = append(allow, method)
+			continue
+		}
+		if r := m.lookup(host, method, path, &lr); r != nil || lr.failed != nil || m.staticAllows(host, method, path) {
+			allow = append(allow, method)
+			hasGet = hasGet || method == http.MethodGet
+		}
 	}
+	if len(allow) == 0 {
+		return nil
+	}
+	return append(allow, http.MethodOptions)
 }
 
-func (m *LiteMux) handleMiddleware(ctx Context) bool {
-	if m.middlewareNum > 0 {
-		for _, mid := range m.middlewareList {
-			if !mid.Handle(ctx) {
-				return false
+func (m *LiteMux) otherMethods(rw http.ResponseWriter, req *http.Request) bool {
+	if req.Method == http.MethodOptions && req.URL.Path == "*" {
+		allow := make([]string, 0, len(methods))
+		for _, method := range methods {
+			if m.trees[method] != nil || method == http.MethodOptions {
+				allow = append(allow, method)
+				continue
+			}
+			for _, h := range m.hosts {
+				if h.trees[method] != nil {
+					allow = append(allow, method)
+					break
+				}
 			}
 		}
+		m.answerOptions(rw, req, allow)
+		return true
 	}
+
+	host, path := stripPort(GetHost(req)), req.URL.EscapedPath()
+	allow := m.allowed(host, path)
+	for _, method := range methods {
+		if allow != nil {
+			break
+		}
+		if fixed := m.fixPath(host, method, path); fixed != path {
+			allow = m.allowed(host, fixed)
+		}
+	}
+	if allow == nil {
+		return false
+	}
+	if req.Method == http.MethodOptions {
+		m.answerOptions(rw, req, allow)
+		return true
+	}
+	rw.Header().Set(AllowHeaderKey, strings.Join(allow, ", "))
+	m.handleMethodNotAllowed(rw, req)
 	return true
 }
 
+// answerOptions replies 204 with allow, through the middleware of the mux
+// and of the party of the request so that e.g. CORS preflights are seen.
+func (m *LiteMux) answerOptions(rw http.ResponseWriter, req *http.Request, allow []string) {
+	value := strings.Join(allow, ", ")
+	party := m.partyOf(req, func(*Router) bool { return true })
+	m.handleContext(rw, req, compose(func(ctx Context) {
+		ctx.Header(AllowHeaderKey, value)
+		ctx.StatusCode(http.StatusNoContent)
+	}, m.middlewareList, party.chain()))
+}
+
+// partyOf returns the router with the longest prefix matching the request
+// among those has accepts, a router of a matching host wins over one without
+// host. The root router is returned when none matches.
+func (m *LiteMux) partyOf(req *http.Request, has func(r *Router) bool) *Router {
+	var lr lookupResult
+	host, path := stripPort(GetHost(req)), req.URL.EscapedPath()
+	party := m.rootRouter
+	for _, r := range m.routers {
+		if !has(r) || len(r.segments) < len(party.segments) ||
+			len(r.segments) == len(party.segments) && (r.host == nil || party.host != nil) {
+			continue
+		}
+		lr.reset()
+		if r.host != nil && !r.host.match(host, &lr) {
+			continue
+		}
+		if r.matchPrefix(path) {
+			party = r
+		}
+	}
+	return party
+}
+
+func hasNotFound(r *Router) bool {
+	return r.notFound != nil
+}
+
+func hasMethodNotAllowed(r *Router) bool {
+	return r.methodNotAllowed != nil
+}
+
+func (m *LiteMux) handleMethodNotAllowed(rw http.ResponseWriter, req *http.Request) {
+	handle := m.methodNotAllowed
+	if r := m.partyOf(req, hasMethodNotAllowed); r.methodNotAllowed != nil {
+		handle = r.methodNotAllowed
+	}
+	if handle == nil {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	m.handleContext(rw, req, func(ctx Context) {
+		ctx.StatusCode(http.StatusMethodNotAllowed)
+		handle(ctx)
+	})
+}
+
+func (m *LiteMux) handleNotFound(rw http.ResponseWriter, req *http.Request) {
+	handle := m.notFound
+	if r := m.partyOf(req, hasNotFound); r.notFound != nil {
+		handle = r.notFound
+	}
+	if handle != nil {
+		m.handleContext(rw, req, handle)
+	} else {
+		http.NotFound(rw, req)
+	}
+}
+
 func (m *LiteMux) serve(rw http.ResponseWriter, req *http.Request) {
 	if _, match := m.parse(rw, req); match != matchOk && match != matchFail {
 		if !m.staticRoute(rw, req) {
@@ -184,50 +511,73 @@ func (m *LiteMux) serve(rw http.ResponseWriter, req *http.Request) {
 	}
 }
 
+// URL builds the path of the route registered under name, see Route.URL.
+func (m *LiteMux) URL(name string, pairs ...string) (string, error) {
+	r, has := m.named[name]
+	if !has {
+		return "", fmt.Errorf("route %s: %w", name, ErrNotFound)
+	}
+	return r.URL(pairs...)
+}
+
 func (m *LiteMux) Party(path string) *Router {
-	return newRouter(path, m)
+	return m.rootRouter.Party(path)
+}
+
+// Handle registers a net/http handler, see Router.Handle.
+func (m *LiteMux) Handle(method string, path string, handler http.Handler, mid ...Middleware) *Route {
+	return m.rootRouter.Handle(method, path, handler, mid...)
 }
 
-func (m *LiteMux) Get(path string, handle HandleFunc) {
-	m.rootRouter.Get(path, handle)
+// Mount serves a prefix with a net/http handler, see Router.Mount.
+func (m *LiteMux) Mount(prefix string, handler http.Handler, mid ...Middleware) {
+	m.rootRouter.Mount(prefix, handler, mid...)
 }
 
-func (m *LiteMux) Post(path string, handle HandleFunc) {
-	m.rootRouter.Post(path, handle)
+func (m *LiteMux) Get(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Get(path, handle, mid...)
 }
 
-func (m *LiteMux) Put(path string, handle HandleFunc) {
-	m.rootRouter.Put(path, handle)
+func (m *LiteMux) Post(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Post(path, handle, mid...)
 }
 
-func (m *LiteMux) Delete(path string, handle HandleFunc) {
-	m.rootRouter.Delete(path, handle)
+func (m *LiteMux) Put(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Put(path, handle, mid...)
 }
 
-func (m *LiteMux) Head(path string, handle HandleFunc) {
-	m.rootRouter.Head(path, handle)
+func (m *LiteMux) Delete(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Delete(path, handle, mid...)
 }
 
-func (m *LiteMux) Patch(path string, handle HandleFunc) {
-	m.rootRouter.Patch(path, handle)
+func (m *LiteMux) Head(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Head(path, handle, mid...)
 }
 
-func (m *LiteMux) Options(path string, handle HandleFunc) {
-	m.rootRouter.Options(path, handle)
+func (m *LiteMux) Patch(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Patch(path, handle, mid...)
 }
 
-func (m *LiteMux) Trace(path string, handle HandleFunc) {
-	m.rootRouter.Trace(path, handle)
+func (m *LiteMux) Options(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Options(path, handle, mid...)
 }
 
-func (m *LiteMux) Connect(path string, handle HandleFunc) {
-	m.rootRouter.Connect(path, handle)
+func (m *LiteMux) Trace(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Trace(path, handle, mid...)
+}
+
+func (m *LiteMux) Connect(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return m.rootRouter.Connect(path, handle, mid...)
 }
 
 func (m *LiteMux) NotFound(handle HandleFunc) {
 	m.notFound = handle
 }
 
+func (m *LiteMux) MethodNotAllowed(handle HandleFunc) {
+	m.methodNotAllowed = handle
+}
+
 func (m *LiteMux) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	m.serve(rw, req)
 }
@@ -1,6 +1,7 @@
 package literoute
 
 import (
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -35,3 +36,38 @@ func BenchmarkLiteMux(b *testing.B) {
 func Bench(ctx Context) {
 	_, _ = ctx.Write([]byte("b"))
 }
+
+func BenchmarkLiteMuxRoutes(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		mux := Default()
+		for i := 0; i < size; i++ {
+			mux.Get(fmt.Sprintf("/static/%d/items", i), benchString)
+			mux.Get(fmt.Sprintf("/param/%d/items/:id", i), benchString)
+		}
+		last := size - 1
+
+		b.Run(fmt.Sprintf("Static%d", size), func(b *testing.B) {
+			request, _ := http.NewRequest("GET", fmt.Sprintf("/static/%d/items", last), nil)
+			response := httptest.NewRecorder()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				mux.ServeHTTP(response, request)
+			}
+		})
+
+		b.Run(fmt.Sprintf("Param%d", size), func(b *testing.B) {
+			request, _ := http.NewRequest("GET", fmt.Sprintf("/param/%d/items/42", last), nil)
+			response := httptest.NewRecorder()
+			b.ReportAllocs()
+			b.ResetTimer()
+			for n := 0; n < b.N; n++ {
+				mux.ServeHTTP(response, request)
+			}
+		})
+	}
+}
+
+func benchString(ctx Context) {
+	_, _ = ctx.WriteString("b")
+}
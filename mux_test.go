@@ -94,3 +94,59 @@ func (m *LogMid) Handle(ctx Context) bool {
 	//})
 	return true
 }
+
+func TestPathPolicy(t *testing.T) {
+	newMux := func(config Config) *LiteMux {
+		mux := New(config)
+		mux.Get("/users/:id", func(ctx Context) {
+			_, _ = ctx.WriteString(ctx.Path() + " " + ctx.Param("id"))
+		})
+		mux.Post("/users", writeName("create"))
+		return mux
+	}
+
+	cases := []struct {
+		name     string
+		config   Config
+		method   string
+		path     string
+		code     int
+		location string
+		body     string
+	}{
+		{"redirect get", Config{}, http.MethodGet, "/users/7/?a=1", http.StatusFound, "/users/7?a=1", ""},
+		{"redirect post", Config{}, http.MethodPost, "/users/", http.StatusPermanentRedirect, "/users", ""},
+		{"redirect unknown", Config{}, http.MethodGet, "/missing/", http.StatusNotFound, "", "404 page not found\n"},
+		{"strict", Config{TrailingSlash: TrailingSlashStrict}, http.MethodGet, "/users/7/", http.StatusNotFound, "", "404 page not found\n"},
+		{"match", Config{TrailingSlash: TrailingSlashMatch}, http.MethodGet, "/users/7/", http.StatusOK, "", "/users/7 7"},
+		{"clean redirect", Config{CleanPath: true}, http.MethodGet, "//users/./x/../7", http.StatusFound, "/users/7", ""},
+		{"clean off", Config{}, http.MethodGet, "//users/7", http.StatusNotFound, "", "404 page not found\n"},
+		{"clean match", Config{CleanPath: true, TrailingSlash: TrailingSlashMatch}, http.MethodGet, "/users//7/", http.StatusOK, "", "/users/7 7"},
+		{"case redirect", Config{CaseInsensitive: true}, http.MethodGet, "/USERS/Ab", http.StatusFound, "/users/Ab", ""},
+		{"case match", Config{CaseInsensitive: true, TrailingSlash: TrailingSlashMatch}, http.MethodPost, "/Users/", http.StatusOK, "", "create"},
+		{"case off", Config{}, http.MethodGet, "/USERS/Ab", http.StatusNotFound, "", "404 page not found\n"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(newMux(c.config), c.method, c.path)
+		if rec.Code != c.code || rec.Header().Get("Location") != c.location || rec.Body.String() != c.body {
+			t.Errorf("%s: %s %s got %d %q %q", c.name, c.method, c.path, rec.Code, rec.Header().Get("Location"), rec.Body.String())
+		}
+	}
+
+	for _, config := range []Config{{}, {TrailingSlash: TrailingSlashMatch}, {CaseInsensitive: true, CleanPath: true}} {
+		mux := newMux(config)
+		for _, path := range []string{"/users/7/", "/USERS//7"} {
+			if path == "/USERS//7" && !config.CaseInsensitive {
+				continue
+			}
+			rec := serveRequest(mux, http.MethodDelete, path)
+			if rec.Code != http.StatusMethodNotAllowed || rec.Header().Get(AllowHeaderKey) != "GET, HEAD, OPTIONS" {
+				t.Errorf("%+v: DELETE %s got %d %q", config, path, rec.Code, rec.Header().Get(AllowHeaderKey))
+			}
+		}
+	}
+	rec := serveRequest(newMux(Config{TrailingSlash: TrailingSlashStrict}), http.MethodDelete, "/users/7/")
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("strict: DELETE /users/7/ got %d", rec.Code)
+	}
+}
@@ -0,0 +1,127 @@
+package literoute
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// acceptRange is a media range of an Accept header, specificity is 0 for
+// */*, 1 for type/* and 2 for type/subtype.
+type acceptRange struct {
+	mediaType   string
+	specificity int
+	q           float64
+}
+
+func parseAccept(header string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		slash := strings.IndexByte(mediaType, '/')
+		if slash <= 0 || slash == len(mediaType)-1 {
+			continue
+		}
+		r := acceptRange{mediaType: mediaType, specificity: 2, q: 1}
+		switch {
+		case mediaType == "*/*":
+			r.specificity = 0
+		case mediaType[slash+1:] == "*":
+			r.specificity = 1
+		}
+		valid := true
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if len(param) < 2 || (param[0] != 'q' && param[0] != 'Q') || param[1] != '=' {
+				continue
+			}
+			q, err := strconv.ParseFloat(param[2:], 64)
+			if err != nil || q < 0 || q > 1 {
+				valid = false
+				break
+			}
+			r.q = q
+		}
+		if valid {
+			ranges = append(ranges, r)
+		}
+	}
+	return ranges
+}
+
+func (r acceptRange) match(mediaType string) bool {
+	switch r.specificity {
+	case 0:
+		return true
+	case 1:
+		return strings.HasPrefix(mediaType, r.mediaType[:len(r.mediaType)-1])
+	default:
+		return r.mediaType == mediaType
+	}
+}
+
+// negotiate returns the index of the offer the Accept header prefers, the
+// most specific range matching an offer gives its quality. Ties go to the
+// offer matched by the more specific range, then to the earlier offer. An
+// empty header accepts the first offer, -1 means nothing is acceptable.
+func negotiate(accept string, offers []string) int {
+	if strings.TrimSpace(accept) == "" {
+		if len(offers) == 0 {
+			return -1
+		}
+		return 0
+	}
+	ranges := parseAccept(accept)
+	best, bestQ, bestSpecificity := -1, 0.0, -1
+	for i, offer := range offers {
+		q, specificity := 0.0, -1
+		for _, r := range ranges {
+			if r.specificity > specificity && r.match(offer) {
+				q, specificity = r.q, r.specificity
+			}
+		}
+		if q > bestQ || q == bestQ && q > 0 && specificity > bestSpecificity {
+			best, bestQ, bestSpecificity = i, q, specificity
+		}
+	}
+	return best
+}
+
+// Negotiate writes v with the registered encoder the Accept header of the
+// request prefers, the one of Config.BodyEncoder when any is accepted.
+// Vary: Accept is set, and when nothing is acceptable the status is 406 and
+// ErrNotAcceptable is returned.
+func (ctx *context) Negotiate(v interface{}) (int, error) {
+	addVary(ctx.writer.Header(), AcceptHeaderKey)
+	types := ctx.Mux().encoderTypes
+	i := negotiate(ctx.GetHeader(AcceptHeaderKey), types)
+	if i < 0 {
+		ctx.StatusCode(http.StatusNotAcceptable)
+		return 0, ErrNotAcceptable
+	}
+	before := ctx.writer.Written()
+	err := ctx.Encode(types[i], v)
+	return writtenSince(before, ctx.writer.Written()), err
+}
+
+func addVary(header http.Header, key string) {
+	for _, value := range header[VaryHeaderKey] {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), key) {
+				return
+			}
+		}
+	}
+	header.Add(VaryHeaderKey, key)
+}
+
+func writtenSince(before, after int) int {
+	if before < 0 {
+		before = 0
+	}
+	if after < before {
+		return 0
+	}
+	return after - before
+}
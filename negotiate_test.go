@@ -0,0 +1,68 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	offers := []string{"application/json", "text/xml", "application/xml"}
+	cases := []struct {
+		accept string
+		want   int
+	}{
+		{"", 0},
+		{"*/*", 0},
+		{"application/xml", 2},
+		{"text/*", 1},
+		{"text/xml;q=0.5, application/json;q=0.4", 1},
+		{"application/*;q=0.2, text/xml;q=0.2", 1},
+		{"*/*;q=0.1, application/json;q=0", 1},
+		{"application/json;q=0, text/*;q=0", -1},
+		{"text/html", -1},
+		{"text/xml;q=x, application/json", 0},
+	}
+	for _, c := range cases {
+		if got := negotiate(c.accept, offers); got != c.want {
+			t.Errorf("Accept %q chose %d, want %d", c.accept, got, c.want)
+		}
+	}
+}
+
+func TestContextNegotiate(t *testing.T) {
+	type todo struct {
+		Name string `json:"name" xml:"name"`
+	}
+	config := DefaultConfig
+	config.Negotiate = true
+	mux := New(config)
+	mux.Get("/todo", func(ctx Context) {
+		ctx.Succeed(todo{Name: "milk"})
+	})
+
+	cases := []struct {
+		accept      string
+		code        int
+		contentType string
+		body        string
+	}{
+		{"", config.Status.Succeed, "application/json", `{"name":"milk"}`},
+		{"application/xml, application/json;q=0.9", config.Status.Succeed, "application/xml", `<todo><name>milk</name></todo>`},
+		{"text/html", http.StatusNotAcceptable, "", ""},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/todo", nil)
+		req.Header.Set("Accept", c.accept)
+		mux.ServeHTTP(rec, req)
+		if rec.Code != c.code || !strings.HasPrefix(rec.Header().Get("Content-Type"), c.contentType) ||
+			strings.TrimSpace(rec.Body.String()) != c.body {
+			t.Errorf("Accept %q got %d %q %q", c.accept, rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+		}
+		if rec.Header().Get("Vary") != "Accept" {
+			t.Errorf("Accept %q got Vary %q", c.accept, rec.Header().Get("Vary"))
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package literoute
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicReportFunc is called with the value and the stack of a panic
+// recovered from a handler, after the response was written.
+type PanicReportFunc func(ctx Context, recovered interface{}, stack []byte)
+
+// PanicReporter sets the hook of recovered panics, they are logged when it
+// is not set.
+func (m *LiteMux) PanicReporter(report PanicReportFunc) {
+	m.panicReporter = report
+}
+
+// handleContext runs handle with a pooled context. A panic of handle is
+// recovered and reported, the context is released in any case, also when
+// the recovery itself panics.
+func (m *LiteMux) handleContext(rw http.ResponseWriter, req *http.Request, handle HandleFunc) {
+	ctx := acquireContext(m, rw, req)
+	defer releaseContext(ctx)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if recovered == http.ErrAbortHandler {
+				panic(recovered)
+			}
+			m.safeRecoverPanic(ctx, recovered, debug.Stack())
+		}
+	}()
+	handle(ctx)
+}
+
+// safeRecoverPanic runs recoverPanic, a panic of the fallback body or of the
+// reporter is logged and the status is set to 500 when nothing was written.
+func (m *LiteMux) safeRecoverPanic(ctx Context, recovered interface{}, stack []byte) {
+	defer func() {
+		if again := recover(); again != nil {
+			if ctx.ResponseWriter().Written() == NoWritten {
+				ctx.ResponseWriter().Header().Del(ContentTypeHeaderKey)
+				ctx.StatusCode(http.StatusInternalServerError)
+			}
+			log.Printf("literoute: panic recovering %s: %v, after panic: %v\n%s", ctx, again, recovered, stack)
+		}
+	}()
+	m.recoverPanic(ctx, recovered, stack)
+}
+
+// recoverPanic writes a Status.Fail response when nothing was written yet
+// and reports the panic.
+func (m *LiteMux) recoverPanic(ctx Context, recovered interface{}, stack []byte) {
+	if ctx.ResponseWriter().Written() == NoWritten {
+		ctx.Fail(map[string]string{"error": http.StatusText(http.StatusInternalServerError)})
+	}
+	if m.panicReporter != nil {
+		m.panicReporter(ctx, recovered, stack)
+		return
+	}
+	log.Printf("literoute: panic serving %s: %v\n%s", ctx, recovered, stack)
+}
@@ -0,0 +1,118 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPanicRecovery(t *testing.T) {
+	mux := Default()
+	var (
+		recovered interface{}
+		stack     string
+	)
+	mux.PanicReporter(func(ctx Context, v interface{}, s []byte) {
+		recovered, stack = v, string(s)
+	})
+	mux.Get("/boom", func(ctx Context) {
+		ctx.StatusCode(http.StatusCreated)
+		panic("boom")
+	})
+	mux.Get("/late", func(ctx Context) {
+		_, _ = ctx.WriteString("partial")
+		panic("late")
+	})
+	mux.NotFound(func(ctx Context) {
+		panic("not found")
+	})
+
+	rec := serveRequest(mux, http.MethodGet, "/boom")
+	if rec.Code != DefaultConfig.Status.Fail || rec.Body.String() != `{"error":"Internal Server Error"}` {
+		t.Errorf("GET /boom got %d %q", rec.Code, rec.Body.String())
+	}
+	if recovered != "boom" || !strings.Contains(stack, "TestPanicRecovery") {
+		t.Errorf("reported %v with stack %s", recovered, stack)
+	}
+
+	rec = serveRequest(mux, http.MethodGet, "/late")
+	if rec.Code != http.StatusOK || rec.Body.String() != "partial" || recovered != "late" {
+		t.Errorf("GET /late got %d %q, reported %v", rec.Code, rec.Body.String(), recovered)
+	}
+
+	rec = serveRequest(mux, http.MethodGet, "/missing")
+	if rec.Code != DefaultConfig.Status.Fail || recovered != "not found" {
+		t.Errorf("GET /missing got %d, reported %v", rec.Code, recovered)
+	}
+}
+
+func TestPanicAbortHandler(t *testing.T) {
+	mux := Default()
+	mux.Get("/", func(ctx Context) {
+		panic(http.ErrAbortHandler)
+	})
+	defer func() {
+		if recover() != http.ErrAbortHandler {
+			t.Errorf("ErrAbortHandler was not repanicked")
+		}
+	}()
+	serveRequest(mux, http.MethodGet, "/")
+}
+
+func TestPanicWrappedMiddleware(t *testing.T) {
+	buffering := WrapMiddleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			buf := httptest.NewRecorder()
+			next.ServeHTTP(buf, req)
+			rw.WriteHeader(buf.Code)
+			_, _ = rw.Write(buf.Body.Bytes())
+		})
+	})
+	mux := Default()
+	mux.PanicReporter(func(Context, interface{}, []byte) {})
+	mux.Get("/boom", func(ctx Context) {
+		_, _ = ctx.WriteString("partial")
+		panic("boom")
+	}, buffering)
+	mux.Get("/ok", writeName("ok"), buffering)
+
+	for i := 0; i < 3; i++ {
+		rec := serveRequest(mux, http.MethodGet, "/boom")
+		if rec.Code != DefaultConfig.Status.Fail || rec.Body.String() != `{"error":"Internal Server Error"}` {
+			t.Errorf("panic behind a buffering middleware got %d %q", rec.Code, rec.Body.String())
+		}
+		rec = serveRequest(mux, http.MethodGet, "/ok")
+		if rec.Code != http.StatusOK || rec.Body.String() != "ok" {
+			t.Errorf("buffered route got %d %q", rec.Code, rec.Body.String())
+		}
+	}
+}
+
+func TestPanicDuringRecovery(t *testing.T) {
+	mux := New(Config{BodyEncoder: "application/msgpack"})
+	mux.RegisterBodyEncoder("application/msgpack", BodyEncoderFunc(func(w http.ResponseWriter, v interface{}) error {
+		panic("encoder")
+	}))
+	mux.Get("/encode", func(ctx Context) {
+		ctx.Succeed("ok")
+	})
+	reporter := New(DefaultConfig)
+	reporter.PanicReporter(func(Context, interface{}, []byte) {
+		panic("reporter")
+	})
+	reporter.Get("/report", func(ctx Context) {
+		panic("boom")
+	})
+
+	for i := 0; i < 3; i++ {
+		rec := serveRequest(mux, http.MethodGet, "/encode")
+		if rec.Code != http.StatusInternalServerError || rec.Header().Get("Content-Type") != "" {
+			t.Errorf("failing fallback body got %d %q", rec.Code, rec.Header().Get("Content-Type"))
+		}
+		rec = serveRequest(reporter, http.MethodGet, "/report")
+		if rec.Code != DefaultConfig.Status.Fail || rec.Body.String() != `{"error":"Internal Server Error"}` {
+			t.Errorf("panicking reporter got %d %q", rec.Code, rec.Body.String())
+		}
+	}
+}
@@ -2,170 +2,233 @@ package literoute
 
 import (
 	context0 "context"
+	"fmt"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 )
 
 const (
-	tokenParam = 2
-	tokenSub   = 4
-	contextKey = "a_lite_route"
-	matchOk    = 1
-	matchNon   = 0
-	matchFail  = -1
+	contextKey     = "a_lite_route"
+	liteContextKey = "a_lite_context"
+	matchOk        = 1
+	matchNon       = 0
+	matchFail      = -1
 )
 
-type token struct {
-	raw    []int
-	Tokens []string
-	Size   int
-}
-
-func newRoute(mux *LiteMux, url string, h HandleFunc) *route {
-	r := &route{Path: url, Handle: h, mux: mux}
+func newRoute(router *Router, url string, h HandleFunc) *Route {
+	r := &Route{path: url, handler: h, mux: router.mux, router: router}
 	r.save()
 	return r
 }
 
-type route struct {
-	Path       string
-	Method     string
-	Size       int
-	Attrs      int
-	Token      token
-	Pattern    map[int]string
-	Handle     HandleFunc
-	mux        *LiteMux
-	validators map[string][]string
+type paramCheck struct {
+	index      int
+	pattern    *regexp.Regexp
+	source     string
+	rules      []string
+	validators []Validator
 }
 
-func (r *route) handle(rw http.ResponseWriter, req *http.Request) {
-	ctx := acquireContext(r.mux, rw, req)
-	if !r.mux.handleMiddleware(ctx) {
-		releaseContext(ctx)
-		return
-	}
-	r.Handle(ctx)
-	releaseContext(ctx)
-}
-
-func (r *route) save() {
-	r.Size = len(r.Path)
-	r.Token.Tokens = strings.Split(r.Path, "/")
-	for i, s := range r.Token.Tokens {
-		if len(s) >= 1 {
-			if s[:1] == ":" {
-				s = s[1:]
-				if r.Pattern == nil {
-					r.Pattern = make(map[int]string)
+type Route struct {
+	path           string
+	method         string
+	params         []string
+	handler        HandleFunc
+	name           string
+	mux            *LiteMux
+	router         *Router
+	middlewareList []Middleware
+	chain          HandleFunc
+	checks         []paramCheck
+	node           *node
+}
+
+// Path returns the path the route was registered with, prefixes included.
+func (r *Route) Path() string {
+	return r.path
+}
+
+// Method returns the method the route was registered for.
+func (r *Route) Method() string {
+	return r.method
+}
+
+// Params returns the names of the path params of the route.
+func (r *Route) Params() []string {
+	return append([]string(nil), r.params...)
+}
+
+func (r *Route) handle(rw http.ResponseWriter, req *http.Request) {
+	r.mux.handleContext(rw, req, r.chain)
+}
+
+func (r *Route) compose() {
+	r.chain = compose(r.handler, r.mux.middlewareList, r.router.chain(), r.middlewareList)
+}
+
+func (r *Route) save() {
+	for _, s := range splitSegments(r.path) {
+		if len(s) >= 1 && (s[:1] == ":" || s[:1] == "*") {
+			spec := parseParam(s[1:])
+			if len(spec.validators) > 0 || spec.pattern != "" {
+				c := paramCheck{index: len(r.params)}
+				if spec.pattern != "" {
+					c.pattern = compilePattern(s[1:], spec.pattern)
+					c.source = spec.pattern
 				}
-				if validators := containsValidators(s); validators != nil {
-					if r.validators == nil {
-						r.validators = make(map[string][]string)
-					}
-					for _, vali := range validators {
-						s = s[:validators[0].start]
-						r.validators[s] = append(r.validators[s], vali.name[1:])
-					}
+				for _, ref := range spec.validators {
+					c.rules = append(c.rules, ref.String())
+					c.validators = append(c.validators, r.router.bindValidator(spec.name, ref))
 				}
-				r.Pattern[i] = s
-				r.Attrs |= tokenParam
-			} else {
-				r.Token.raw = append(r.Token.raw, i)
+				r.checks = append(r.checks, c)
 			}
+			r.params = append(r.params, spec.name)
 		}
-		r.Token.Size++
 	}
 }
 
-func (r *route) match(rw http.ResponseWriter, req *http.Request) int {
-	mr, _ := r.matchAndParse(rw, req)
-	return mr
-}
-
-func (r *route) matchAndParse(rw http.ResponseWriter, req *http.Request) (int, map[string]string) {
-	ss := strings.Split(req.URL.EscapedPath(), "/")
-	if r.matchRawTokens(&ss) {
-		if len(ss) == r.Token.Size {
-			totalSize := len(r.Pattern)
-			vars := make(map[string]string, totalSize)
-			for k, v := range r.Pattern {
-				if validators := r.validators[v]; validators != nil {
-					for _, validatorName := range validators {
-						validator := (*r.mux).validators[validatorName]
-						if !validator.Validate(ss[k]) {
-							ctx := acquireContext(r.mux, rw, req)
-							validator.OnFail(ctx)
-							releaseContext(ctx)
-							return matchFail, nil
-						}
-					}
-				}
-				vars[v], _ = url.QueryUnescape(ss[k])
+func (r *Route) check(lr *lookupResult) Validator {
+	for _, c := range r.checks {
+		for _, validator := range c.validators {
+			if !validator.Validate(lr.param(lr.base + c.index).value) {
+				return validator
 			}
-			return matchOk, vars
 		}
 	}
-	return matchNon, nil
+	return nil
 }
 
-func (r *route) parse(rw http.ResponseWriter, req *http.Request) (bool, int) {
-	if r.Attrs != 0 {
-		if r.Attrs&tokenSub != 0 {
-			if len(req.URL.Path) >= r.Size {
-				if req.URL.Path[:r.Size] == r.Path {
-					req.URL.Path = req.URL.Path[r.Size:]
-					r.handle(rw, req)
-					return true, matchOk
-				}
-			}
+// matchStatic reports whether a route registered with a trailing slash
+// serves path, every path below it is served.
+func (r *Route) matchStatic(host string, path string) bool {
+	if r.router.host != nil {
+		var lr lookupResult
+		if !r.router.host.match(host, &lr) {
+			return false
 		}
+	}
+	return strings.HasPrefix(path, r.path)
+}
+
+func (r *Route) host() string {
+	if r.router.host == nil {
+		return ""
+	}
+	return r.router.host.pattern
+}
 
-		if mr, vars := r.matchAndParse(rw, req); mr == matchOk {
-			ctx0 := context0.WithValue(req.Context(), contextKey, vars)
-			newReq := req.WithContext(ctx0)
-			r.handle(rw, newReq)
-			return true, matchOk
-		} else if mr == matchFail {
-			return true, matchFail
-		} else if mr == matchNon {
-			return false, matchNon
+// shape is the path with the param names left out, routes of the same
+// method and shape match exactly the same requests.
+func (r *Route) shape() string {
+	segments := splitSegments(r.path)
+	for i, s := range segments {
+		if len(s) > 0 && (s[0] == ':' || s[0] == '*') {
+			spec := parseParam(s[1:])
+			shape := s[:1]
+			if spec.pattern != "" {
+				shape += "<" + spec.pattern + ">"
+			}
+			for _, ref := range spec.validators {
+				shape += "|" + ref.String()
+			}
+			segments[i] = shape
 		}
 	}
-	if req.URL.Path == r.Path {
-		r.handle(rw, req)
-		return true, matchOk
+	return strings.Join(segments, "/")
+}
+
+// Name names the route for reverse routing with LiteMux.URL and
+// Context.URLFor, names are unique per mux.
+func (r *Route) Name(name string) *Route {
+	if other, has := r.mux.named[name]; has && other != r {
+		panic("literoute: route name " + name + " is already used by " + other.path)
+	}
+	if r.name != "" {
+		delete(r.mux.named, r.name)
 	}
-	return false, matchNon
+	r.name = name
+	r.mux.named[name] = r
+	return r
 }
 
-func (r *route) matchRawTokens(ss *[]string) bool {
-	if len(*ss) >= r.Token.Size {
-		for _, v := range r.Token.raw {
-			return (*ss)[v] == r.Token.Tokens[v]
+// URL fills the params of the route path with the values of pairs, given as
+// key, value, key, value... Values are escaped and must pass the pattern and
+// validators of their param.
+func (r *Route) URL(pairs ...string) (string, error) {
+	if len(pairs)%2 != 0 {
+		return "", fmt.Errorf("route %s: odd number of param pairs", r.path)
+	}
+	var (
+		b     strings.Builder
+		index int
+	)
+	for i, s := range splitSegments(r.path) {
+		if i > 0 {
+			b.WriteByte('/')
+		}
+		if len(s) == 0 || (s[0] != ':' && s[0] != '*') {
+			b.WriteString(s)
+			continue
+		}
+		name := r.params[index]
+		value, found := "", false
+		for j := 0; j < len(pairs); j += 2 {
+			if pairs[j] == name {
+				value, found = pairs[j+1], true
+				break
+			}
+		}
+		if !found || (value == "" && s[0] == ':') {
+			return "", fmt.Errorf("route %s: missing value for param %s", r.path, name)
 		}
-		return true
+		var escaped string
+		if s[0] == '*' {
+			parts := strings.Split(value, "/")
+			for k, part := range parts {
+				parts[k] = url.PathEscape(part)
+			}
+			escaped = strings.Join(parts, "/")
+		} else {
+			escaped = url.PathEscape(value)
+		}
+		if err := r.checkValue(index, value, escaped); err != nil {
+			return "", err
+		}
+		b.WriteString(escaped)
+		index++
 	}
-	return false
+	return b.String(), nil
 }
 
-func (r *route) exists(rw http.ResponseWriter, req *http.Request) bool {
-	if r.Attrs != 0 {
-		if r.Attrs&tokenSub != 0 {
-			if len(req.URL.Path) >= r.Size {
-				if req.URL.Path[:r.Size] == r.Path {
-					return true
-				}
+func (r *Route) checkValue(index int, value string, escaped string) error {
+	for _, c := range r.checks {
+		if c.index != index {
+			continue
+		}
+		if c.pattern != nil && !c.pattern.MatchString(value) {
+			return fmt.Errorf("route %s: value %q of param %s does not match %s", r.path, value, r.params[index], c.pattern)
+		}
+		for i, validator := range c.validators {
+			if !validator.Validate(escaped) {
+				return fmt.Errorf("route %s: value %q of param %s rejected by %s", r.path, value, r.params[index], c.rules[i])
 			}
 		}
+	}
+	return nil
+}
 
-		if mr, _ := r.matchAndParse(rw, req); mr == matchOk || mr == matchFail {
-			return true
-		}
+func (r *Route) serve(rw http.ResponseWriter, req *http.Request, lr *lookupResult) {
+	if lr.size == 0 {
+		r.handle(rw, req)
+		return
 	}
-	if req.URL.Path == r.Path {
-		return true
+	vars := make(map[string]string, lr.size)
+	for i := 0; i < lr.size; i++ {
+		p := lr.param(i)
+		vars[p.key], _ = url.PathUnescape(p.value)
 	}
-	return false
+	ctx0 := context0.WithValue(req.Context(), contextKey, vars)
+	r.handle(rw, req.WithContext(ctx0))
 }
@@ -0,0 +1,126 @@
+package literoute
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// RouteInfo describes a registered route, Params lists the host captures
+// then the path params and Validators maps a param to its inline pattern,
+// written as <pattern>, and its validators.
+type RouteInfo struct {
+	Method     string              `json:"method"`
+	Host       string              `json:"host,omitempty"`
+	Path       string              `json:"path"`
+	Name       string              `json:"name,omitempty"`
+	Params     []string            `json:"params,omitempty"`
+	Validators map[string][]string `json:"validators,omitempty"`
+	Middleware []string            `json:"middleware,omitempty"`
+}
+
+func (r *Route) Info() RouteInfo {
+	info := RouteInfo{
+		Method: r.method,
+		Host:   r.host(),
+		Path:   r.path,
+		Name:   r.name,
+	}
+	if r.router.host != nil {
+		info.Params = r.router.host.params()
+	}
+	info.Params = append(info.Params, r.params...)
+	for _, c := range r.checks {
+		var rules []string
+		if c.pattern != nil {
+			rules = append(rules, "<"+c.source+">")
+		}
+		rules = append(rules, c.rules...)
+		if info.Validators == nil {
+			info.Validators = make(map[string][]string)
+		}
+		info.Validators[r.params[c.index]] = rules
+	}
+	for _, list := range [][]Middleware{r.mux.middlewareList, r.router.chain(), r.middlewareList} {
+		for _, mid := range list {
+			info.Middleware = append(info.Middleware, middlewareName(mid))
+		}
+	}
+	return info
+}
+
+// Routes returns the registered routes ordered by path, host and method.
+func (m *LiteMux) Routes() []RouteInfo {
+	var infos []RouteInfo
+	for _, routes := range m.routes {
+		for _, r := range routes {
+			infos = append(infos, r.Info())
+		}
+	}
+	sort.Slice(infos, func(i, j int) bool {
+		if infos[i].Path != infos[j].Path {
+			return infos[i].Path < infos[j].Path
+		}
+		if infos[i].Host != infos[j].Host {
+			return infos[i].Host < infos[j].Host
+		}
+		return infos[i].Method < infos[j].Method
+	})
+	return infos
+}
+
+// RoutesHandler serves the route table of the mux as JSON, or as text when
+// the query has format=text or the client accepts text/plain.
+func (m *LiteMux) RoutesHandler() HandleFunc {
+	return func(ctx Context) {
+		routes := m.Routes()
+		if ctx.URLParam("format") == "text" ||
+			ctx.URLParam("format") == "" && strings.Contains(ctx.GetHeader("Accept"), ContentTextHeaderValue) {
+			ctx.ContentType(ContentTextHeaderValue)
+			_, _ = ctx.WriteString(FormatRoutes(routes))
+			return
+		}
+		_, _ = ctx.JSON(routes)
+	}
+}
+
+// FormatRoutes renders routes as an aligned text table.
+func FormatRoutes(routes []RouteInfo) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 4, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "METHOD\tPATH\tNAME\tVALIDATORS\tMIDDLEWARE")
+	for _, r := range routes {
+		var validators []string
+		for _, param := range r.Params {
+			if rules := r.Validators[param]; len(rules) > 0 {
+				validators = append(validators, param+":"+strings.Join(rules, "|"))
+			}
+		}
+		_, _ = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", r.Method, r.Host+r.Path, dash(r.Name),
+			dash(strings.Join(validators, " ")), dash(strings.Join(r.Middleware, ",")))
+	}
+	_ = w.Flush()
+	return b.String()
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func middlewareName(mid Middleware) string {
+	if named, ok := mid.(fmt.Stringer); ok {
+		return named.String()
+	}
+	if v := reflect.ValueOf(mid); v.Kind() == reflect.Func {
+		if f := runtime.FuncForPC(v.Pointer()); f != nil {
+			return f.Name()
+		}
+	}
+	return fmt.Sprintf("%T", mid)
+}
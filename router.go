@@ -2,65 +2,266 @@ package literoute
 
 import (
 	"net/http"
+	"net/url"
 	"strings"
 )
 
 type HandleFunc func(ctx Context)
 
 type Router struct {
-	prefix string
-	mux    *LiteMux
+	prefix           string
+	mux              *LiteMux
+	parent           *Router
+	middlewareList   []Middleware
+	validators       map[string]Validator
+	paramValidators  map[string]ParamValidator
+	segments         []string
+	host             *hostRoutes
+	notFound         HandleFunc
+	methodNotAllowed HandleFunc
 }
 
 func newRouter(path string, mux *LiteMux) *Router {
-	return &Router{
+	r := &Router{
 		prefix: strings.TrimSuffix(path, "/"),
 		mux:    mux,
 	}
+	if r.prefix != "" {
+		r.segments = splitSegments(r.prefix[1:])
+	}
+	return r
+}
+
+// Party returns a sub router, its prefix is appended to the prefix of r and
+// it inherits the middleware and validators of r.
+func (r *Router) Party(path string) *Router {
+	router := newRouter(joinPath(r.prefix, path), r.mux)
+	router.parent = r
+	router.host = r.host
+	r.mux.routers = append(r.mux.routers, router)
+	return router
+}
+
+// NotFound sets the handler for unmatched paths under the prefix of the
+// router, the party with the longest matching prefix wins.
+func (r *Router) NotFound(handle HandleFunc) {
+	r.notFound = handle
+}
+
+// MethodNotAllowed sets the handler for paths under the prefix of the router
+// that are registered for other methods only, the status is already 405.
+func (r *Router) MethodNotAllowed(handle HandleFunc) {
+	r.methodNotAllowed = handle
+}
+
+func (r *Router) matchPrefix(path string) bool {
+	for _, segment := range r.segments {
+		if len(path) == 0 || path[0] != '/' {
+			return false
+		}
+		path = path[1:]
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		switch {
+		case strings.HasPrefix(segment, "*"):
+			return true
+		case strings.HasPrefix(segment, ":"):
+			if end == 0 {
+				return false
+			}
+		case segment != path[:end]:
+			return false
+		}
+		path = path[end:]
+	}
+	return true
+}
+
+// RegisterValidator registers a validator visible to the routes of the
+// router and of its sub routers only.
+func (r *Router) RegisterValidator(name string, validator Validator) {
+	if r.validators == nil {
+		r.validators = make(map[string]Validator)
+	}
+	r.validators[name] = validator
+}
+
+func (r *Router) RegisterParamValidator(name string, validator ParamValidator) {
+	if r.paramValidators == nil {
+		r.paramValidators = make(map[string]ParamValidator)
+	}
+	r.paramValidators[name] = validator
+}
+
+func (r *Router) bindValidator(param string, ref validatorRef) Validator {
+	validator, found := bindValidator(r.validators, r.paramValidators, ref)
+	if !found {
+		if r.parent != nil {
+			return r.parent.bindValidator(param, ref)
+		}
+		if validator, found = bindValidator(r.mux.validators, r.mux.paramValidators, ref); !found {
+			panic("literoute: validator " + ref.name + " is not registered")
+		}
+	}
+	if binder, ok := validator.(paramBinder); ok {
+		return binder.bindParam(param)
+	}
+	return validator
+}
+
+// Use appends middleware run for every route of the router, after the
+// middleware of the mux and of the parent routers.
+func (r *Router) Use(mid ...Middleware) {
+	r.middlewareList = append(r.middlewareList, mid...)
+	r.mux.compose()
+}
+
+func (r *Router) UseFunc(mid ...MiddlewareFunc) {
+	for _, m := range mid {
+		r.middlewareList = append(r.middlewareList, m)
+	}
+	r.mux.compose()
 }
 
-func (r *Router) register(method string, path string, handle HandleFunc) {
-	route := newRoute(r.mux, r.prefix+path, handle)
-	route.Method = method
+func (r *Router) chain() []Middleware {
+	if r.parent == nil {
+		return r.middlewareList
+	}
+	parent := r.parent.chain()
+	chain := make([]Middleware, 0, len(parent)+len(r.middlewareList))
+	return append(append(chain, parent...), r.middlewareList...)
+}
+
+func (r *Router) register(method string, path string, handle HandleFunc, mid []Middleware) *Route {
+	route := newRoute(r, joinPath(r.prefix, path), handle)
+	if r.host != nil {
+		for _, name := range r.host.params() {
+			for _, param := range route.params {
+				if param == name {
+					panic("literoute: param " + name + " of " + route.path + " is also captured by host " + r.host.pattern)
+				}
+			}
+		}
+	}
+	route.method = method
+	route.middlewareList = mid
+	route.compose()
 	if valid(path) {
-		r.mux.routes[method] = append(r.mux.routes[method], route)
-		return
+		r.mux.addRoute(method, route)
+		return route
 	}
+	r.mux.claim(static+" "+method+" "+route.path, route)
 	r.mux.routes[static] = append(r.mux.routes[static], route)
+	return route
+}
+
+func (r *Router) Get(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodGet, path, handle, mid)
+}
+
+func (r *Router) Post(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodPost, path, handle, mid)
+}
+
+func (r *Router) Put(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodPut, path, handle, mid)
+}
+
+func (r *Router) Delete(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodDelete, path, handle, mid)
 }
 
-func (r *Router) Get(path string, handle HandleFunc) {
-	r.register(http.MethodGet, path, handle)
+func (r *Router) Head(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodHead, path, handle, mid)
 }
 
-func (r *Router) Post(path string, handle HandleFunc) {
-	r.register(http.MethodPost, path, handle)
+func (r *Router) Patch(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodPatch, path, handle, mid)
 }
 
-func (r *Router) Put(path string, handle HandleFunc) {
-	r.register(http.MethodPut, path, handle)
+func (r *Router) Options(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodOptions, path, handle, mid)
 }
 
-func (r *Router) Delete(path string, handle HandleFunc) {
-	r.register(http.MethodDelete, path, handle)
+func (r *Router) Trace(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodTrace, path, handle, mid)
 }
 
-func (r *Router) Head(path string, handle HandleFunc) {
-	r.register(http.MethodHead, path, handle)
+func (r *Router) Connect(path string, handle HandleFunc, mid ...Middleware) *Route {
+	return r.register(http.MethodConnect, path, handle, mid)
 }
 
-func (r *Router) Patch(path string, handle HandleFunc) {
-	r.register(http.MethodPatch, path, handle)
+// Handle registers a net/http handler, it runs after the middleware of the
+// router and sees the params through Context values of the request.
+func (r *Router) Handle(method string, path string, handler http.Handler, mid ...Middleware) *Route {
+	return r.register(method, path, handlerOf(handler), mid)
 }
 
-func (r *Router) Options(path string, handle HandleFunc) {
-	r.register(http.MethodOptions, path, handle)
+// Mount serves every method for prefix and the paths below it with handler.
+// The handler gets a copy of the request whose URL has the prefix stripped,
+// Path and RawPath alike.
+func (r *Router) Mount(prefix string, handler http.Handler, mid ...Middleware) {
+	prefix = strings.TrimSuffix(prefix, "/")
+	skip := strings.Count(joinPath(r.prefix, prefix), "/")
+	h := func(ctx Context) {
+		handler.ServeHTTP(ctx.ResponseWriter(), withContext(ctx, stripSegments(ctx.Request(), skip)))
+	}
+	for _, method := range methods {
+		if prefix != "" || r.prefix != "" {
+			r.register(method, prefix, h, mid)
+		}
+		r.register(method, prefix+"/*mountpath", h, mid)
+	}
+}
+
+func handlerOf(handler http.Handler) HandleFunc {
+	return func(ctx Context) {
+		handler.ServeHTTP(ctx.ResponseWriter(), withContext(ctx, ctx.Request()))
+	}
+}
+
+// stripSegments returns a shallow copy of req without the first n segments
+// of its path.
+func stripSegments(req *http.Request, n int) *http.Request {
+	escaped := req.URL.EscapedPath()
+	rest := escaped
+	for i := 0; i < n && rest != ""; i++ {
+		end := strings.IndexByte(rest[1:], '/')
+		if end < 0 {
+			rest = ""
+			break
+		}
+		rest = rest[end+1:]
+	}
+	if rest == "" {
+		rest = "/"
+	}
+	return withPath(req, rest)
 }
 
-func (r *Router) Trace(path string, handle HandleFunc) {
-	r.register(http.MethodTrace, path, handle)
+// withPath returns a shallow copy of req with the escaped path set, RawPath
+// is only kept when req had one.
+func withPath(req *http.Request, escaped string) *http.Request {
+	r2 := new(http.Request)
+	*r2 = *req
+	r2.URL = new(url.URL)
+	*r2.URL = *req.URL
+	if p, err := url.PathUnescape(escaped); err == nil {
+		r2.URL.Path = p
+	} else {
+		r2.URL.Path = escaped
+	}
+	if req.URL.RawPath != "" {
+		r2.URL.RawPath = escaped
+	}
+	return r2
 }
 
-func (r *Router) Connect(path string, handle HandleFunc) {
-	r.register(http.MethodConnect, path, handle)
+func joinPath(prefix string, path string) string {
+	if prefix != "" && path == "/" {
+		return prefix
+	}
+	return prefix + path
 }
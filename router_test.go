@@ -0,0 +1,425 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNestedParty(t *testing.T) {
+	mux := Default()
+	api := mux.Party("/api")
+	api.Use(&traceMid{name: "api", allow: true})
+	api.RegisterValidator("IsInt", &IsIntValidator{})
+
+	v1 := api.Party("/v1/")
+	v1.Use(&traceMid{name: "v1", allow: true})
+	v1.Get("/", writeName("root"))
+	v1.Get("/users/:id|IsInt", func(ctx Context) {
+		_, _ = ctx.WriteString("user " + ctx.Param("id"))
+	})
+
+	tenant := v1.Party("/tenants/:tid")
+	tenant.Get("/projects/:pid", func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Param("tid") + "/" + ctx.Param("pid"))
+	})
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/api/v1", "api>v1>root"},
+		{"/api/v1/users/42", "api>v1>user 42"},
+		{"/api/v1/tenants/acme/projects/7", "api>v1>acme/7"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, http.MethodGet, c.path)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("GET %s wrote %q, want %q", c.path, got, c.want)
+		}
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("validator of a party was visible outside of it")
+			}
+		}()
+		mux.Get("/users/:id|IsInt", writeName("user"))
+	}()
+}
+
+func TestPartyNotFound(t *testing.T) {
+	mux := Default()
+	mux.NotFound(func(ctx Context) {
+		ctx.NotFound()
+		_, _ = ctx.WriteString("mux")
+	})
+
+	api := mux.Party("/api")
+	api.NotFound(func(ctx Context) {
+		ctx.NotFound()
+		_, _ = ctx.JSON(map[string]string{"error": "not found"})
+	})
+	api.MethodNotAllowed(func(ctx Context) {
+		_, _ = ctx.JSON(map[string]string{"error": "method not allowed"})
+	})
+	api.Get("/users", writeName("users"))
+	tenant := api.Party("/tenants/:tid")
+	tenant.Get("/info", writeName("info"))
+
+	web := mux.Party("/web")
+	web.NotFound(func(ctx Context) {
+		ctx.NotFound()
+		_, _ = ctx.HTML("<h1>%s</h1>", "not found")
+	})
+	web.Post("/form", writeName("form"))
+
+	mux.Party("/docs").Get("/intro", writeName("intro"))
+	mux.Party("/docs").NotFound(func(ctx Context) {
+		ctx.NotFound()
+		_, _ = ctx.WriteString("docs")
+	})
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		want   string
+	}{
+		{http.MethodGet, "/missing", DefaultConfig.Status.NotFound, "mux"},
+		{http.MethodGet, "/api/missing", DefaultConfig.Status.NotFound, `{"error":"not found"}`},
+		{http.MethodGet, "/api/tenants/acme/missing", DefaultConfig.Status.NotFound, `{"error":"not found"}`},
+		{http.MethodPost, "/api/users", http.StatusMethodNotAllowed, `{"error":"method not allowed"}`},
+		{http.MethodGet, "/web/missing", DefaultConfig.Status.NotFound, "<h1>not found</h1>"},
+		{http.MethodGet, "/web/form", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/webs", DefaultConfig.Status.NotFound, "mux"},
+		{http.MethodGet, "/docs/missing", DefaultConfig.Status.NotFound, "docs"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, c.method, c.path)
+		if rec.Code != c.code || rec.Body.String() != c.want {
+			t.Errorf("%s %s got %d %q, want %d %q", c.method, c.path, rec.Code, rec.Body.String(), c.code, c.want)
+		}
+	}
+}
+
+func TestAllowAndOptions(t *testing.T) {
+	mux := Default()
+	mux.Get("/todo/:id", writeName("get"))
+	mux.Delete("/todo/:id", writeName("delete"))
+	mux.Post("/todo", writeName("post"))
+	mux.Put("/custom", writeName("put"))
+	mux.Options("/custom", func(ctx Context) {
+		ctx.Header(AllowHeaderKey, "PUT")
+		ctx.StatusCode(http.StatusOK)
+	})
+
+	cases := []struct {
+		method string
+		path   string
+		code   int
+		allow  string
+	}{
+		{http.MethodPost, "/todo/1", http.StatusMethodNotAllowed, "GET, DELETE, HEAD, OPTIONS"},
+		{http.MethodOptions, "/todo/1", http.StatusNoContent, "GET, DELETE, HEAD, OPTIONS"},
+		{http.MethodGet, "/todo", http.StatusMethodNotAllowed, "POST, OPTIONS"},
+		{http.MethodOptions, "/custom", http.StatusOK, "PUT"},
+		{http.MethodOptions, "/missing", http.StatusNotFound, ""},
+		{http.MethodOptions, "*", http.StatusNoContent, "GET, POST, PUT, DELETE, OPTIONS"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(c.method, "/", nil)
+		req.URL.Path = c.path
+		mux.ServeHTTP(rec, req)
+		if rec.Code != c.code || rec.Header().Get(AllowHeaderKey) != c.allow {
+			t.Errorf("%s %s got %d Allow %q, want %d %q", c.method, c.path, rec.Code, rec.Header().Get(AllowHeaderKey), c.code, c.allow)
+		}
+	}
+
+	cors := func(origin string) MiddlewareFunc {
+		return func(ctx Context, next HandleFunc) {
+			ctx.Header("Access-Control-Allow-Origin", origin)
+			next(ctx)
+		}
+	}
+	mux = Default()
+	mux.AppendMiddlewareFunc(cors("*"))
+	api := mux.Party("/api")
+	api.UseFunc(cors("https://example.com"))
+	api.Get("/todo", writeName("get"))
+	rec := serveRequest(mux, http.MethodOptions, "/api/todo")
+	if rec.Code != http.StatusNoContent || rec.Header().Get(AllowHeaderKey) != "GET, HEAD, OPTIONS" ||
+		strings.Join(rec.Header()["Access-Control-Allow-Origin"], " ") != "* https://example.com" {
+		t.Errorf("preflight got %d %v", rec.Code, rec.Header())
+	}
+}
+
+func TestRouteURL(t *testing.T) {
+	mux := Default()
+	mux.Get("/todo/:name", writeName("todo")).Name("todo.show")
+	post := mux.Get("/users/:id|Int/posts/:slug<[a-z-]+>", writeName("post")).Name("post")
+	if post.Method() != http.MethodGet || post.Path() != "/users/:id|Int/posts/:slug<[a-z-]+>" {
+		t.Errorf("unexpected route %s %s", post.Method(), post.Path())
+	}
+	if params := post.Params(); len(params) != 2 || params[0] != "id" || params[1] != "slug" {
+		t.Errorf("unexpected params %q", params)
+	}
+	mux.Get("/files/*filepath", writeName("file")).Name("files")
+	mux.Get("/todo/:name/redirect", func(ctx Context) {
+		target, err := ctx.URLFor("todo.show", "name", ctx.Param("name"))
+		if err != nil {
+			ctx.Fail(err.Error())
+			return
+		}
+		ctx.Redirect(target)
+	})
+
+	cases := []struct {
+		name  string
+		pairs []string
+		want  string
+		err   bool
+	}{
+		{"todo.show", []string{"name", "42"}, "/todo/42", false},
+		{"todo.show", []string{"name", "a b/c"}, "/todo/a%20b%2Fc", false},
+		{"todo.show", nil, "", true},
+		{"post", []string{"id", "7", "slug", "hello-world"}, "/users/7/posts/hello-world", false},
+		{"post", []string{"id", "x", "slug", "hello"}, "", true},
+		{"post", []string{"id", "7", "slug", "Hello"}, "", true},
+		{"files", []string{"filepath", "docs/a b.txt"}, "/files/docs/a%20b.txt", false},
+		{"missing", nil, "", true},
+	}
+	for _, c := range cases {
+		got, err := mux.URL(c.name, c.pairs...)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("URL(%s, %q) = %q, %v", c.name, c.pairs, got, err)
+		}
+	}
+
+	rec := serveRequest(mux, http.MethodGet, "/todo/a%20b/redirect")
+	if rec.Code != http.StatusFound || rec.Header().Get("Location") != "/todo/a%20b" {
+		t.Errorf("redirect got %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+	if got := serveRequest(mux, http.MethodGet, "/todo/a%20b%2Fc").Body.String(); got != "todo" {
+		t.Errorf("built URL not routed back, got %q", got)
+	}
+}
+
+type namedMid struct{}
+
+func (m *namedMid) Handle(ctx Context) bool {
+	return true
+}
+
+func (m *namedMid) String() string {
+	return "auth"
+}
+
+func TestRoutes(t *testing.T) {
+	mux := Default()
+	mux.AppendMiddleware(&countMid{})
+	api := mux.Party("/api")
+	api.Use(&namedMid{})
+	api.Get("/users/:id<[0-9]+>|Range(1,100)", writeName("user")).Name("user")
+	mux.Get("/healthz", writeName("health"))
+	mux.Get("/routes", mux.RoutesHandler())
+
+	routes := mux.Routes()
+	if len(routes) != 3 {
+		t.Fatalf("got %d routes", len(routes))
+	}
+	user := routes[0]
+	if user.Path != "/api/users/:id<[0-9]+>|Range(1,100)" || user.Name != "user" || user.Method != http.MethodGet {
+		t.Errorf("unexpected route %+v", user)
+	}
+	if rules := user.Validators["id"]; len(rules) != 2 || rules[0] != "<[0-9]+>" || rules[1] != "Range(1,100)" {
+		t.Errorf("unexpected validators %q", rules)
+	}
+	if len(user.Middleware) != 2 || user.Middleware[0] != "*literoute.countMid" || user.Middleware[1] != "auth" {
+		t.Errorf("unexpected middleware %q", user.Middleware)
+	}
+
+	rec := serveRequest(mux, http.MethodGet, "/routes")
+	if !strings.HasPrefix(rec.Body.String(), `[{"method":"GET","path":"/api/users/`) {
+		t.Errorf("unexpected json table %s", rec.Body.String())
+	}
+	rec = serveRequest(mux, http.MethodGet, "/routes?format=text")
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 4 || !strings.HasPrefix(lines[0], "METHOD") {
+		t.Errorf("unexpected text table %s", rec.Body.String())
+	}
+}
+
+func TestRoutesHosts(t *testing.T) {
+	mux := Default()
+	mux.Host("{tenant}.example.com").Get("/users/:id", writeName("tenant"))
+	mux.Get("/users/:id", writeName("any"))
+	mux.Host("api.example.com").Get("/users/:id", writeName("api"))
+	mux.Host("admin.example.com").Get("/users/:id", writeName("admin"))
+
+	for i := 0; i < 10; i++ {
+		var hosts []string
+		for _, r := range mux.Routes() {
+			hosts = append(hosts, r.Host)
+		}
+		if got := strings.Join(hosts, ","); got != ",admin.example.com,api.example.com,{tenant}.example.com" {
+			t.Fatalf("routes ordered by host as %q", got)
+		}
+	}
+	tenant := mux.Routes()[3]
+	if len(tenant.Params) != 2 || tenant.Params[0] != "tenant" || tenant.Params[1] != "id" {
+		t.Errorf("unexpected params %q", tenant.Params)
+	}
+}
+
+func TestRouteConflicts(t *testing.T) {
+	conflicts := [][]string{
+		{"/todo/:name", "/todo/:id"},
+		{"/todo/new", "/todo/new"},
+		{"/todo/:a|Int/x", "/todo/:b|Int/x"},
+		{"/files/*path", "/files/*rest"},
+		{"/static/", "/static/"},
+	}
+	for _, paths := range conflicts {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %q did not panic", paths)
+				}
+			}()
+			mux := Default()
+			for _, path := range paths {
+				mux.Get(path, writeName(path))
+			}
+		}()
+	}
+
+	mux := Default()
+	mux.Get("/todo/:name", writeName("name"))
+	mux.Get("/todo/:id|Int", writeName("id"))
+	mux.Post("/todo/:id", writeName("post"))
+	mux.Get("/todo/:name/edit", writeName("edit"))
+
+	config := DefaultConfig
+	config.AllowOverride = true
+	mux = New(config)
+	mux.Get("/todo/:name", writeName("name")).Name("todo")
+	mux.Get("/todo/:id", writeName("id"))
+	if got := serveRequest(mux, http.MethodGet, "/todo/1").Body.String(); got != "id" {
+		t.Errorf("override served by %q", got)
+	}
+	if _, err := mux.URL("todo", "name", "1"); err == nil {
+		t.Error("name of the replaced route still resolves")
+	}
+	if routes := mux.Routes(); len(routes) != 1 || routes[0].Path != "/todo/:id" {
+		t.Errorf("unexpected routes %+v", routes)
+	}
+
+	mux = Default()
+	mux.Get("/s/", writeName("get"))
+	mux.Post("/s/", writeName("post"))
+	for method, want := range map[string]string{http.MethodGet: "get", http.MethodHead: "get", http.MethodPost: "post"} {
+		if rec := serveRequest(mux, method, "/s/x"); rec.Code != http.StatusOK || rec.Body.String() != want {
+			t.Errorf("%s /s/x got %d %q", method, rec.Code, rec.Body.String())
+		}
+	}
+	if rec := serveRequest(mux, http.MethodPut, "/s/x"); rec.Code != http.StatusMethodNotAllowed || rec.Header().Get(AllowHeaderKey) != "GET, POST, HEAD, OPTIONS" {
+		t.Errorf("PUT /s/x got %d %q", rec.Code, rec.Header().Get(AllowHeaderKey))
+	}
+}
+
+func TestHostRouting(t *testing.T) {
+	mux := Default()
+	mux.Get("/", writeName("default"))
+	api := mux.Host("api.example.com")
+	api.Get("/", writeName("api"))
+	tenant := mux.Host("{tenant}.example.com")
+	tenant.Get("/", func(ctx Context) {
+		_, _ = ctx.WriteString("tenant " + ctx.Param("tenant"))
+	})
+	tenant.Party("/projects").Get("/:id", func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Param("tenant") + " project " + ctx.Param("id"))
+	})
+	tenant.NotFound(func(ctx Context) {
+		ctx.NotFound()
+		_, _ = ctx.WriteString("tenant not found")
+	})
+
+	cases := []struct {
+		host string
+		path string
+		want string
+	}{
+		{"api.example.com", "/", "api"},
+		{"API.example.com:8080", "/", "api"},
+		{"acme.example.com", "/", "tenant acme"},
+		{"acme.example.com:443", "/projects/7", "acme project 7"},
+		{"acme.example.com", "/missing", "tenant not found"},
+		{"example.com", "/", "default"},
+		{"a.b.example.com", "/", "default"},
+		{"localhost:8080", "/projects/7", "404 page not found\n"},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, c.path, nil)
+		req.Host = c.host
+		mux.ServeHTTP(rec, req)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("GET %s%s served by %q, want %q", c.host, c.path, got, c.want)
+		}
+	}
+
+	if routes := mux.Routes(); routes[0].Host == "" && routes[1].Host == "" {
+		t.Errorf("host missing from route info %+v", routes)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("param named like a host capture did not panic")
+		}
+	}()
+	mux.Host("{id}.example.com").Party("/u").Get("/:id", writeName("user"))
+}
+
+func TestMount(t *testing.T) {
+	mux := Default()
+	api := mux.Party("/api")
+	api.Use(&traceMid{name: "api", allow: true})
+	var original string
+	api.Mount("/files", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte(req.Method + " " + req.URL.Path + " " + req.URL.RawPath))
+	}))
+	api.Handle(http.MethodGet, "/std/:id", http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		params, _ := req.Context().Value(contextKey).(map[string]string)
+		_, _ = rw.Write([]byte("std " + params["id"]))
+	}))
+	mux.AppendMiddleware(MiddlewareFunc(func(ctx Context, next HandleFunc) {
+		req := ctx.Request()
+		next(ctx)
+		original = req.URL.Path
+	}))
+
+	cases := []struct {
+		method string
+		path   string
+		want   string
+		orig   string
+	}{
+		{http.MethodGet, "/api/files", "api>GET / ", "/api/files"},
+		{http.MethodGet, "/api/files/", "api>GET / ", "/api/files/"},
+		{http.MethodPut, "/api/files/a/b.txt", "api>PUT /a/b.txt ", "/api/files/a/b.txt"},
+		{http.MethodGet, "/api/files/a%2Fb", "api>GET /a/b /a%2Fb", "/api/files/a/b"},
+		{http.MethodGet, "/api/std/7", "api>std 7", "/api/std/7"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, c.method, c.path)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("%s %s served %q, want %q", c.method, c.path, got, c.want)
+		}
+		if original != c.orig {
+			t.Errorf("%s %s changed the original path to %q", c.method, c.path, original)
+		}
+	}
+}
@@ -0,0 +1,400 @@
+package literoute
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	staticNode = iota
+	paramNode
+	catchAllNode
+)
+
+type node struct {
+	kind      int
+	prefix    string
+	name      string
+	validated bool
+	pattern   *regexp.Regexp
+	indices   string
+	children  []*node
+	params    []*node
+	catchAll  *node
+	route     *Route
+}
+
+func newTree() *node {
+	return &node{kind: staticNode}
+}
+
+type pathParam struct {
+	key   string
+	value string
+}
+
+type lookupResult struct {
+	size   int
+	base   int
+	buf    [8]pathParam
+	more   []pathParam
+	failed Validator
+}
+
+func (lr *lookupResult) reset() {
+	lr.size = 0
+	lr.base = 0
+	lr.failed = nil
+}
+
+func (lr *lookupResult) push(key string, value string) {
+	if lr.size < len(lr.buf) {
+		lr.buf[lr.size] = pathParam{key: key, value: value}
+	} else {
+		lr.more = append(lr.more[:lr.size-len(lr.buf)], pathParam{key: key, value: value})
+	}
+	lr.size++
+}
+
+func (lr *lookupResult) pop() {
+	lr.size--
+}
+
+func (lr *lookupResult) param(i int) pathParam {
+	if i < len(lr.buf) {
+		return lr.buf[i]
+	}
+	return lr.more[i-len(lr.buf)]
+}
+
+func (n *node) insert(path string, r *Route) {
+	if path == "" {
+		n.route = r
+		r.node = n
+		return
+	}
+
+	if path[0] == ':' {
+		end := segmentEnd(path)
+		raw := path[1:end]
+		for _, child := range n.params {
+			if child.prefix == raw {
+				child.insert(path[end:], r)
+				return
+			}
+		}
+		child := newParamNode(paramNode, raw)
+		n.addParam(child)
+		child.insert(path[end:], r)
+		return
+	}
+
+	if path[0] == '*' {
+		raw := path[1:]
+		if segmentEnd(raw) != len(raw) {
+			panic("literoute: catch-all segment must be the last segment of the path")
+		}
+		if n.catchAll == nil || n.catchAll.route == nil {
+			n.catchAll = newParamNode(catchAllNode, raw)
+		} else if n.catchAll.prefix != raw {
+			panic("literoute: catch-all segment *" + raw + " conflicts with *" + n.catchAll.prefix)
+		}
+		n.catchAll.route = r
+		r.node = n.catchAll
+		return
+	}
+
+	static := path
+	if i := nextDynamic(path); i >= 0 {
+		static = path[:i+1]
+	}
+
+	for i := 0; i < len(n.indices); i++ {
+		if n.indices[i] != static[0] {
+			continue
+		}
+		child := n.children[i]
+		l := commonPrefix(child.prefix, static)
+		if l < len(child.prefix) {
+			split := &node{
+				kind:     staticNode,
+				prefix:   child.prefix[:l],
+				indices:  child.prefix[l : l+1],
+				children: []*node{child},
+			}
+			child.prefix = child.prefix[l:]
+			n.children[i] = split
+			child = split
+		}
+		child.insert(path[l:], r)
+		return
+	}
+
+	child := &node{kind: staticNode, prefix: static}
+	n.indices += static[:1]
+	n.children = append(n.children, child)
+	child.insert(path[len(static):], r)
+}
+
+// addParam keeps param children ordered by priority: params carrying
+// validators are tried before plain ones, registration order breaks ties.
+func (n *node) addParam(child *node) {
+	i := len(n.params)
+	if child.validated {
+		for i > 0 && !n.params[i-1].validated {
+			i--
+		}
+	}
+	n.params = append(n.params, nil)
+	copy(n.params[i+1:], n.params[i:])
+	n.params[i] = child
+}
+
+// lookup walks the tree by priority: static children first, then params,
+// then the catch-all. A route whose validators reject the request is skipped
+// so that a less specific route may still match, the first rejecting
+// validator is kept.
+func (n *node) lookup(path string, lr *lookupResult) *Route {
+	if path == "" {
+		if n.route != nil {
+			return n.accept(lr)
+		}
+		return n.lookupCatchAll(path, lr)
+	}
+
+	if i := strings.IndexByte(n.indices, path[0]); i >= 0 {
+		child := n.children[i]
+		if len(path) >= len(child.prefix) && path[:len(child.prefix)] == child.prefix {
+			if r := child.lookup(path[len(child.prefix):], lr); r != nil {
+				return r
+			}
+		}
+	}
+
+	if len(n.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			for _, child := range n.params {
+				if !child.allow(path[:end]) {
+					continue
+				}
+				lr.push(child.name, path[:end])
+				if r := child.lookup(path[end:], lr); r != nil {
+					return r
+				}
+				lr.pop()
+			}
+		}
+	}
+
+	return n.lookupCatchAll(path, lr)
+}
+
+func (n *node) lookupCatchAll(path string, lr *lookupResult) *Route {
+	if n.catchAll == nil || !n.catchAll.allow(path) {
+		return nil
+	}
+	lr.push(n.catchAll.name, path)
+	if r := n.catchAll.accept(lr); r != nil {
+		return r
+	}
+	lr.pop()
+	return nil
+}
+
+// fixCase appends path to fixed with the static parts spelled as in the
+// tree, when a route matches path ignoring case. Validators are not run.
+func (n *node) fixCase(path string, fixed []byte) ([]byte, bool) {
+	if path == "" {
+		if n.route != nil || n.catchAll != nil && n.catchAll.route != nil {
+			return fixed, true
+		}
+		return nil, false
+	}
+
+	for _, child := range n.children {
+		if len(path) >= len(child.prefix) && strings.EqualFold(path[:len(child.prefix)], child.prefix) {
+			if out, ok := child.fixCase(path[len(child.prefix):], append(fixed, child.prefix...)); ok {
+				return out, true
+			}
+		}
+	}
+
+	if len(n.params) > 0 {
+		end := strings.IndexByte(path, '/')
+		if end < 0 {
+			end = len(path)
+		}
+		if end > 0 {
+			for _, child := range n.params {
+				if !child.allow(path[:end]) {
+					continue
+				}
+				if out, ok := child.fixCase(path[end:], append(fixed, path[:end]...)); ok {
+					return out, true
+				}
+			}
+		}
+	}
+
+	if n.catchAll != nil && n.catchAll.route != nil && n.catchAll.allow(path) {
+		return append(fixed, path...), true
+	}
+	return nil, false
+}
+
+func (n *node) accept(lr *lookupResult) *Route {
+	if v := n.route.check(lr); v != nil {
+		if lr.failed == nil {
+			lr.failed = v
+		}
+		return nil
+	}
+	return n.route
+}
+
+func nextDynamic(path string) int {
+	for i := 0; i+1 < len(path); i++ {
+		if path[i] == '/' && (path[i+1] == ':' || path[i+1] == '*') {
+			return i
+		}
+	}
+	return -1
+}
+
+func newParamNode(kind int, raw string) *node {
+	spec := parseParam(raw)
+	n := &node{kind: kind, prefix: raw, name: spec.name}
+	if spec.pattern != "" {
+		n.pattern = compilePattern(raw, spec.pattern)
+	}
+	n.validated = n.pattern != nil || len(spec.validators) > 0
+	return n
+}
+
+func compilePattern(raw string, pattern string) *regexp.Regexp {
+	compiled, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		panic(fmt.Sprintf("literoute: invalid pattern in param %s: %v", raw, err))
+	}
+	return compiled
+}
+
+// allow reports whether the segment satisfies the inline pattern of the
+// param, a segment rejected here is not a match at all.
+func (n *node) allow(segment string) bool {
+	if n.pattern == nil {
+		return true
+	}
+	if strings.IndexByte(segment, '%') >= 0 {
+		segment, _ = url.PathUnescape(segment)
+	}
+	return n.pattern.MatchString(segment)
+}
+
+type paramSpec struct {
+	name       string
+	pattern    string
+	validators []validatorRef
+}
+
+// parseParam splits a param token such as "id<[0-9]+>|IsInt" into its name,
+// inline pattern and validator names.
+func parseParam(raw string) (spec paramSpec) {
+	end := strings.IndexAny(raw, "<|")
+	if end < 0 {
+		spec.name = raw
+		return
+	}
+	spec.name = raw[:end]
+	rest := raw[end:]
+	if rest[0] == '<' {
+		closing := patternEnd(rest)
+		if closing < 0 {
+			panic("literoute: unclosed pattern in param " + raw)
+		}
+		spec.pattern = rest[1:closing]
+		rest = rest[closing+1:]
+	}
+	if rest == "" {
+		return
+	}
+	validators := containsValidators(rest)
+	if validators == nil || validators[0].start != 0 {
+		panic("literoute: unexpected characters after pattern in param " + raw)
+	}
+	for _, vali := range validators {
+		spec.validators = append(spec.validators, parseValidatorRef(vali.name[1:]))
+	}
+	return
+}
+
+func patternEnd(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '<':
+			depth++
+		case '>':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// segmentEnd returns the index of the slash closing the first segment of
+// path, slashes inside an inline pattern do not count.
+func segmentEnd(path string) int {
+	depth := 0
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case '\\':
+			i++
+		case '<':
+			depth++
+		case '>':
+			if depth > 0 {
+				depth--
+			}
+		case '/':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(path)
+}
+
+func splitSegments(path string) []string {
+	var segments []string
+	for {
+		end := segmentEnd(path)
+		if end >= len(path) {
+			return append(segments, path)
+		}
+		segments = append(segments, path[:end])
+		path = path[end+1:]
+	}
+}
+
+func commonPrefix(a, b string) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
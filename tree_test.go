@@ -0,0 +1,227 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func writeName(name string) HandleFunc {
+	return func(ctx Context) {
+		_, _ = ctx.WriteString(name)
+	}
+}
+
+func serveRequest(mux *LiteMux, method string, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+	return rec
+}
+
+func TestTreeMatch(t *testing.T) {
+	mux := Default()
+	mux.Get("/", writeName("root"))
+	mux.Get("/todo", writeName("list"))
+	mux.Get("/todos", writeName("plural"))
+	mux.Get("/todo/:name", func(ctx Context) {
+		_, _ = ctx.WriteString("todo " + ctx.Param("name"))
+	})
+	mux.Get("/todo/:name/:var", func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Param("name") + "=" + ctx.Param("var"))
+	})
+	mux.Post("/todo", writeName("create"))
+
+	cases := []struct {
+		method string
+		path   string
+		want   string
+	}{
+		{http.MethodGet, "/", "root"},
+		{http.MethodGet, "/todo", "list"},
+		{http.MethodGet, "/todos", "plural"},
+		{http.MethodGet, "/todo/milk", "todo milk"},
+		{http.MethodGet, "/todo/milk/2", "milk=2"},
+		{http.MethodPost, "/todo", "create"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, c.method, c.path)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("%s %s served by %q, want %q", c.method, c.path, got, c.want)
+		}
+	}
+
+	for _, path := range []string{"/tod", "/todo/milk/2/3", "/other"} {
+		if rec := serveRequest(mux, http.MethodGet, path); rec.Code != http.StatusNotFound {
+			t.Errorf("GET %s got %d, want %d", path, rec.Code, http.StatusNotFound)
+		}
+	}
+	if rec := serveRequest(mux, http.MethodPut, "/todo"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("PUT /todo got %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
+
+type registration struct {
+	path string
+	name string
+}
+
+func TestRoutePriority(t *testing.T) {
+	routes := []registration{
+		{"/todo/new", "static"},
+		{"/todo/:id|IsInt", "validated"},
+		{"/todo/:name", "param"},
+		{"/todo/:name/edit", "edit"},
+		{"/todo/new/edit", "static-edit"},
+	}
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/todo/new", "static"},
+		{"/todo/42", "validated"},
+		{"/todo/abc", "param"},
+		{"/todo/new/edit", "static-edit"},
+		{"/todo/42/edit", "edit"},
+		{"/todo/newer", "param"},
+	}
+
+	orders := map[string][]int{
+		"forward": {0, 1, 2, 3, 4},
+		"reverse": {4, 3, 2, 1, 0},
+		"mixed":   {2, 4, 0, 3, 1},
+	}
+	for orderName, order := range orders {
+		mux := Default()
+		mux.RegisterValidator("IsInt", &IsIntValidator{})
+		for _, i := range order {
+			mux.Get(routes[i].path, writeName(routes[i].name))
+		}
+		for _, c := range cases {
+			rec := serveRequest(mux, http.MethodGet, c.path)
+			if got := rec.Body.String(); got != c.want {
+				t.Errorf("%s: GET %s served by %q, want %q", orderName, c.path, got, c.want)
+			}
+		}
+	}
+}
+
+func TestRoutePriorityValidatorFallback(t *testing.T) {
+	mux := Default()
+	mux.RegisterValidator("IsInt", &IsIntValidator{})
+	mux.Get("/todo/:id|IsInt", writeName("validated"))
+
+	rec := serveRequest(mux, http.MethodGet, "/todo/abc")
+	if rec.Code != DefaultConfig.Status.Fail {
+		t.Fatalf("validator OnFail not invoked, got status %d", rec.Code)
+	}
+
+	mux.Get("/todo/:name", writeName("param"))
+	rec = serveRequest(mux, http.MethodGet, "/todo/abc")
+	if got := rec.Body.String(); got != "param" {
+		t.Fatalf("rejected value served by %q, want %q", got, "param")
+	}
+}
+
+func TestRouteParams(t *testing.T) {
+	mux := Default()
+	mux.Get("/todo/:name/:var", func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Param("name") + "," + ctx.Param("var"))
+	})
+
+	rec := serveRequest(mux, http.MethodGet, "/todo/a%20b/c")
+	if got := rec.Body.String(); got != "a b,c" {
+		t.Fatalf("got params %q", got)
+	}
+	if rec := serveRequest(mux, http.MethodGet, "/todo/a"); rec.Code != http.StatusNotFound {
+		t.Fatalf("partial path matched with status %d", rec.Code)
+	}
+}
+
+type countMid struct {
+	count int
+}
+
+func (m *countMid) Handle(ctx Context) bool {
+	m.count++
+	return true
+}
+
+func TestRouteCatchAll(t *testing.T) {
+	mux := Default()
+	mid := &countMid{}
+	mux.AppendMiddleware(mid)
+	mux.Get("/files/readme", writeName("static"))
+	mux.Get("/files/:dir/info", writeName("info"))
+	mux.Get("/files/*filepath", func(ctx Context) {
+		_, _ = ctx.WriteString("files:" + ctx.Param("filepath"))
+	})
+
+	cases := []struct {
+		path string
+		want string
+	}{
+		{"/files/readme", "static"},
+		{"/files/docs/info", "info"},
+		{"/files/docs/info/more", "files:docs/info/more"},
+		{"/files/a/b.txt", "files:a/b.txt"},
+		{"/files/a+b%20c.txt", "files:a+b c.txt"},
+		{"/files/", "files:"},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, http.MethodGet, c.path)
+		if got := rec.Body.String(); got != c.want {
+			t.Errorf("GET %s served by %q, want %q", c.path, got, c.want)
+		}
+	}
+	if mid.count != len(cases) {
+		t.Errorf("middleware ran %d times, want %d", mid.count, len(cases))
+	}
+	if rec := serveRequest(mux, http.MethodPost, "/files/a/b.txt"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST to catch-all got status %d", rec.Code)
+	}
+}
+
+func TestRouteInlinePattern(t *testing.T) {
+	mux := Default()
+	mux.Get("/users/:id<[0-9]{1,8}>", writeName("id"))
+	mux.Get("/users/:name<[a-z ]+>", writeName("name"))
+	mux.Get("/files/*path<.+\\.png>", writeName("png"))
+
+	cases := []struct {
+		path string
+		want string
+		code int
+	}{
+		{"/users/42", "id", http.StatusOK},
+		{"/users/123456789", "", http.StatusNotFound},
+		{"/users/bob", "name", http.StatusOK},
+		{"/users/bob%20smith", "name", http.StatusOK},
+		{"/users/Bob", "", http.StatusNotFound},
+		{"/users/bob+smith", "", http.StatusNotFound},
+		{"/files/img/a.png", "png", http.StatusOK},
+		{"/files/img/a.gif", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, http.MethodGet, c.path)
+		if rec.Code != c.code {
+			t.Errorf("GET %s got status %d, want %d", c.path, rec.Code, c.code)
+		}
+		if c.want != "" && rec.Body.String() != c.want {
+			t.Errorf("GET %s served by %q, want %q", c.path, rec.Body.String(), c.want)
+		}
+	}
+}
+
+func TestParseParam(t *testing.T) {
+	spec := parseParam("id<a|b>|IsInt|NotZero")
+	if spec.name != "id" || spec.pattern != "a|b" || len(spec.validators) != 2 || spec.validators[1].name != "NotZero" {
+		t.Fatalf("unexpected spec %+v", spec)
+	}
+	spec = parseParam("age|Range(18, 120)")
+	if ref := spec.validators[0]; ref.name != "Range" || len(ref.args) != 2 || ref.args[1] != "120" {
+		t.Fatalf("unexpected validator %+v", ref)
+	}
+	if segments := splitSegments("/a/:d<\\d+/\\d+>/b"); len(segments) != 4 || segments[2] != ":d<\\d+/\\d+>" {
+		t.Fatalf("unexpected segments %q", segments)
+	}
+}
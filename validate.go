@@ -0,0 +1,534 @@
+package literoute
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationErrors maps the path of each invalid field, e.g. "items[0].name",
+// to the message of the first rule it broke. Context.Invalid writes it as is.
+type ValidationErrors map[string]string
+
+func (e ValidationErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for i, field := range fields {
+		fields[i] = field + " " + e[field]
+	}
+	return strings.Join(fields, "; ")
+}
+
+// ValidateStruct checks the validate tags of v, a struct or a pointer to one,
+// and of the structs it holds in fields, slices and maps. Rules are separated
+// by commas:
+//
+//	required, omitempty, min=n, max=n, len=n, gt=n, gte=n, lt=n, lte=n,
+//	oneof=a b c, email, url, uuid, ulid, alpha, alphanum, hex,
+//	eqfield=F, nefield=F, gtfield=F, gtefield=F, ltfield=F, ltefield=F
+//
+// min, max, len, gt, gte, lt and lte count the characters of strings and the
+// items of slices and maps, and compare numbers. Rules after dive apply to
+// each item of a slice or map. Fields are named after their json, form, url,
+// path, header or cookie tag. A rule that is unknown or does not fit its
+// field is returned as a *RuleError.
+func ValidateStruct(v interface{}) error {
+	errs := ValidationErrors{}
+	if err := validateValue(reflect.ValueOf(v), "", errs); err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// RuleError reports a validate tag rule that cannot be applied to a field.
+type RuleError struct {
+	Struct reflect.Type
+	Field  string
+	Rule   string
+	Err    error
+}
+
+func (e *RuleError) Error() string {
+	return fmt.Sprintf("literoute: validate rule %s on field %s of %s: %v", e.Rule, e.Field, e.Struct, e.Err)
+}
+
+func (e *RuleError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	structRules sync.Map // reflect.Type -> compiledRules
+	nameTags    = []string{"json", "form", "url", "path", "header", "cookie"}
+)
+
+// compiledRules caches the rules of a struct type, or the error of its tags.
+type compiledRules struct {
+	fields []fieldRules
+	err    error
+}
+
+type fieldRules struct {
+	index     int
+	name      string
+	omitempty bool
+	required  bool
+	rules     []structRule
+	dive      []structRule
+	nested    bool
+}
+
+type structRule struct {
+	message string
+	check   func(v reflect.Value, parent reflect.Value) bool
+}
+
+func validateValue(v reflect.Value, path string, errs ValidationErrors) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			return validateValue(v.Elem(), path, errs)
+		}
+	case reflect.Struct:
+		if v.Type() != timeType {
+			return validateStruct(v, path, errs)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), path+"["+strconv.Itoa(i)+"]", errs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := validateValue(iter.Value(), path+"["+fmt.Sprint(iter.Key().Interface())+"]", errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, prefix string, errs ValidationErrors) error {
+	rules, err := rulesOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, f := range rules {
+		field := v.Field(f.index)
+		path := f.name
+		if prefix != "" && path != "" {
+			path = prefix + "." + path
+		} else if path == "" {
+			path = prefix
+		}
+		if msg := checkRules(f, field, v); msg != "" {
+			errs[path] = msg
+			continue
+		}
+		if len(f.dive) > 0 {
+			diveRules(f.dive, indirect(field), v, path, errs)
+		}
+		if f.nested {
+			if err := validateValue(field, path, errs); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func checkRules(f fieldRules, field reflect.Value, parent reflect.Value) string {
+	value := indirect(field)
+	if !value.IsValid() || value.IsZero() {
+		if f.required {
+			return "is required"
+		}
+		if f.omitempty || !value.IsValid() {
+			return ""
+		}
+	}
+	for _, rule := range f.rules {
+		if !rule.check(value, parent) {
+			return rule.message
+		}
+	}
+	return ""
+}
+
+func diveRules(rules []structRule, v reflect.Value, parent reflect.Value, path string, errs ValidationErrors) {
+	check := func(item reflect.Value, itemPath string) {
+		item = indirect(item)
+		for _, rule := range rules {
+			if item.IsValid() && !rule.check(item, parent) {
+				errs[itemPath] = rule.message
+				return
+			}
+		}
+	}
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			check(v.Index(i), path+"["+strconv.Itoa(i)+"]")
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			check(iter.Value(), path+"["+fmt.Sprint(iter.Key().Interface())+"]")
+		}
+	}
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if compiled, ok := structRules.Load(t); ok {
+		return compiled.(compiledRules).fields, compiled.(compiledRules).err
+	}
+	var rules []fieldRules
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue
+		}
+		f := fieldRules{index: i, name: fieldName(sf), nested: holdsStructs(sf.Type)}
+		tag := sf.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+		if tag != "" {
+			if err := parseRules(t, sf, tag, &f); err != nil {
+				structRules.Store(t, compiledRules{err: err})
+				return nil, err
+			}
+		}
+		if f.required || f.omitempty || len(f.rules) > 0 || len(f.dive) > 0 || f.nested {
+			rules = append(rules, f)
+		}
+	}
+	structRules.Store(t, compiledRules{fields: rules})
+	return rules, nil
+}
+
+func fieldName(sf reflect.StructField) string {
+	for _, tag := range nameTags {
+		name := sf.Tag.Get(tag)
+		if i := strings.IndexByte(name, ','); i >= 0 {
+			name = name[:i]
+		}
+		if name != "" && name != "-" {
+			return name
+		}
+	}
+	if sf.Anonymous {
+		return ""
+	}
+	return sf.Name
+}
+
+// holdsStructs reports whether values of t may contain structs to validate.
+func holdsStructs(t reflect.Type) bool {
+	for {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Array, reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			return t != timeType
+		case reflect.Interface:
+			return true
+		default:
+			return false
+		}
+	}
+}
+
+func parseRules(parent reflect.Type, sf reflect.StructField, tag string, f *fieldRules) error {
+	t := sf.Type
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	dive := false
+	for _, raw := range strings.Split(tag, ",") {
+		name, arg := raw, ""
+		if i := strings.IndexByte(raw, '='); i >= 0 {
+			name, arg = raw[:i], raw[i+1:]
+		}
+		switch name {
+		case "":
+			continue
+		case "required":
+			f.required = true
+			continue
+		case "omitempty":
+			f.omitempty = true
+			continue
+		case "dive":
+			if t.Kind() != reflect.Slice && t.Kind() != reflect.Array && t.Kind() != reflect.Map {
+				return &RuleError{Struct: parent, Field: sf.Name, Rule: raw, Err: fmt.Errorf("not a slice or map: %s", sf.Type)}
+			}
+			dive = true
+			t = t.Elem()
+			for t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+			continue
+		}
+		rule, err := compileRule(parent, t, name, arg)
+		if err != nil {
+			return &RuleError{Struct: parent, Field: sf.Name, Rule: raw, Err: err}
+		}
+		if dive {
+			f.dive = append(f.dive, rule)
+		} else {
+			f.rules = append(f.rules, rule)
+		}
+	}
+	return nil
+}
+
+var formatRules = map[string]struct {
+	message string
+	check   func(string) bool
+}{
+	"email":    {"must be an email address", isEmail},
+	"url":      {"must be an absolute URL", isAbsoluteURL},
+	"uuid":     {"must be a UUID", uuidRegex.MatchString},
+	"ulid":     {"must be a ULID", ulidRegex.MatchString},
+	"alpha":    {"must contain only letters", alphaRegex.MatchString},
+	"alphanum": {"must contain only letters and digits", alphaNumRegex.MatchString},
+	"hex":      {"must be hexadecimal", hexRegex.MatchString},
+}
+
+var fieldRuleMessages = map[string]string{
+	"eqfield":  "must be equal to %s",
+	"nefield":  "must not be equal to %s",
+	"gtfield":  "must be greater than %s",
+	"gtefield": "must be greater than or equal to %s",
+	"ltfield":  "must be less than %s",
+	"ltefield": "must be less than or equal to %s",
+}
+
+func compileRule(parent reflect.Type, t reflect.Type, name string, arg string) (structRule, error) {
+	if format, ok := formatRules[name]; ok {
+		if t.Kind() != reflect.String {
+			return structRule{}, fmt.Errorf("needs a string, got %s", t)
+		}
+		return structRule{message: format.message, check: func(v reflect.Value, _ reflect.Value) bool {
+			return format.check(v.String())
+		}}, nil
+	}
+	if message, ok := fieldRuleMessages[name]; ok {
+		return compileFieldRule(parent, t, name, arg, message)
+	}
+
+	switch name {
+	case "min", "max", "len", "gt", "gte", "lt", "lte":
+		n, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return structRule{}, fmt.Errorf("invalid number %q", arg)
+		}
+		unit, ok := sizeUnit(t)
+		if !ok {
+			return structRule{}, fmt.Errorf("needs a number, string, slice or map, got %s", t)
+		}
+		switch name {
+		case "min", "gte":
+			return structRule{message: sizeMessage("at least "+arg, unit), check: func(v reflect.Value, _ reflect.Value) bool {
+				return sizeOf(v) >= n
+			}}, nil
+		case "max", "lte":
+			return structRule{message: sizeMessage("at most "+arg, unit), check: func(v reflect.Value, _ reflect.Value) bool {
+				return sizeOf(v) <= n
+			}}, nil
+		case "gt":
+			return structRule{message: sizeMessage("more than "+arg, unit), check: func(v reflect.Value, _ reflect.Value) bool {
+				return sizeOf(v) > n
+			}}, nil
+		case "lt":
+			return structRule{message: sizeMessage("less than "+arg, unit), check: func(v reflect.Value, _ reflect.Value) bool {
+				return sizeOf(v) < n
+			}}, nil
+		default:
+			return structRule{message: sizeMessage("exactly "+arg, unit), check: func(v reflect.Value, _ reflect.Value) bool {
+				return sizeOf(v) == n
+			}}, nil
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		if len(options) == 0 {
+			return structRule{}, fmt.Errorf("no options")
+		}
+		if _, ok := sizeUnit(t); !ok || t.Kind() == reflect.Slice || t.Kind() == reflect.Map || t.Kind() == reflect.Array {
+			return structRule{}, fmt.Errorf("needs a string or a number, got %s", t)
+		}
+		return structRule{message: "must be one of " + strings.Join(options, ", "), check: func(v reflect.Value, _ reflect.Value) bool {
+			s := valueString(v)
+			for _, option := range options {
+				if s == option {
+					return true
+				}
+			}
+			return false
+		}}, nil
+	}
+	return structRule{}, fmt.Errorf("unknown rule")
+}
+
+func compileFieldRule(parent reflect.Type, t reflect.Type, name string, arg string, message string) (structRule, error) {
+	other, ok := parent.FieldByName(arg)
+	if !ok {
+		return structRule{}, fmt.Errorf("no field %s", arg)
+	}
+	ot := other.Type
+	for ot.Kind() == reflect.Ptr {
+		ot = ot.Elem()
+	}
+	if !comparableKinds(t, ot) {
+		return structRule{}, fmt.Errorf("can not compare %s with %s", t, ot)
+	}
+	ordered := name != "eqfield" && name != "nefield"
+	if ordered && t.Kind() == reflect.String {
+		return structRule{}, fmt.Errorf("can not order %s", t)
+	}
+	index := other.Index
+	message = fmt.Sprintf(message, fieldName(other))
+	return structRule{message: message, check: func(v reflect.Value, p reflect.Value) bool {
+		o := indirect(p.FieldByIndex(index))
+		if !o.IsValid() {
+			return true
+		}
+		c := compareValues(v, o)
+		switch name {
+		case "eqfield":
+			return c == 0
+		case "nefield":
+			return c != 0
+		case "gtfield":
+			return c > 0
+		case "gtefield":
+			return c >= 0
+		case "ltfield":
+			return c < 0
+		default:
+			return c <= 0
+		}
+	}}, nil
+}
+
+const (
+	unitNumber = iota
+	unitChars
+	unitItems
+)
+
+func sizeUnit(t reflect.Type) (int, bool) {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return unitNumber, true
+	case reflect.String:
+		return unitChars, true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return unitItems, true
+	}
+	return 0, false
+}
+
+func sizeMessage(bound string, unit int) string {
+	switch unit {
+	case unitChars:
+		return "must be " + bound + " characters long"
+	case unitItems:
+		return "must contain " + bound + " items"
+	default:
+		return "must be " + bound
+	}
+}
+
+func sizeOf(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String()))
+	default:
+		return float64(v.Len())
+	}
+}
+
+func comparableKinds(a reflect.Type, b reflect.Type) bool {
+	if a == timeType || b == timeType {
+		return a == b
+	}
+	ua, okA := sizeUnit(a)
+	ub, okB := sizeUnit(b)
+	return okA && okB && ua == ub && ua != unitItems
+}
+
+// compareValues orders two numbers, strings or times of the same unit.
+func compareValues(a reflect.Value, b reflect.Value) int {
+	switch {
+	case a.Type() == timeType:
+		ta, tb := a.Interface().(time.Time), b.Interface().(time.Time)
+		switch {
+		case ta.Before(tb):
+			return -1
+		case ta.After(tb):
+			return 1
+		}
+		return 0
+	case a.Kind() == reflect.String:
+		return strings.Compare(a.String(), b.String())
+	}
+	na, nb := sizeOf(a), sizeOf(b)
+	switch {
+	case na < nb:
+		return -1
+	case na > nb:
+		return 1
+	}
+	return 0
+}
+
+func valueString(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	}
+	return v.String()
+}
+
+func isAbsoluteURL(s string) bool {
+	u, err := url.Parse(s)
+	return err == nil && u.Scheme != "" && u.Host != ""
+}
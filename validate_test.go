@@ -0,0 +1,268 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type validateItem struct {
+	SKU      string `json:"sku" validate:"required,len=8,alphanum"`
+	Quantity int    `json:"quantity" validate:"min=1,max=100"`
+}
+
+type validateAddress struct {
+	City string `json:"city" validate:"required"`
+}
+
+type validateOrder struct {
+	ID        string            `json:"id" validate:"ulid"`
+	Email     string            `json:"email" validate:"required,email"`
+	Website   string            `json:"website" validate:"omitempty,url"`
+	Status    string            `json:"status" validate:"oneof=open closed"`
+	Priority  int               `json:"priority" validate:"oneof=1 2 3"`
+	Items     []validateItem    `json:"items" validate:"min=1"`
+	Tags      []string          `json:"tags" validate:"max=3,dive,min=2"`
+	Address   *validateAddress  `json:"address"`
+	Extra     map[string]string `json:"extra" validate:"dive,hex"`
+	Password  string            `json:"password"`
+	Confirm   string            `json:"confirm" validate:"eqfield=Password"`
+	StartsAt  time.Time         `json:"starts_at"`
+	EndsAt    time.Time         `json:"ends_at" validate:"gtfield=StartsAt"`
+	MinPrice  float64           `json:"min_price"`
+	MaxPrice  float64           `json:"max_price" validate:"gtefield=MinPrice"`
+	Internal  string            `json:"-" form:"internal" validate:"-"`
+	unchecked string
+}
+
+func validOrder() validateOrder {
+	now := time.Now()
+	return validateOrder{
+		ID:       "01ARZ3NDEKTSV4RRFFQ69G5FAV",
+		Email:    "a@example.com",
+		Status:   "open",
+		Priority: 2,
+		Items:    []validateItem{{SKU: "AB12CD34", Quantity: 3}},
+		Tags:     []string{"go", "web"},
+		Address:  &validateAddress{City: "Oslo"},
+		Extra:    map[string]string{"color": "ff00aa"},
+		Password: "secret",
+		Confirm:  "secret",
+		StartsAt: now,
+		EndsAt:   now.Add(time.Hour),
+		MinPrice: 1,
+		MaxPrice: 1,
+	}
+}
+
+func TestValidateStruct(t *testing.T) {
+	order := validOrder()
+	if err := ValidateStruct(&order); err != nil {
+		t.Fatalf("valid order rejected: %v", err)
+	}
+
+	order.ID = "x"
+	order.Email = ""
+	order.Website = "example.com"
+	order.Status = "lost"
+	order.Priority = 4
+	order.Items = append(order.Items, validateItem{SKU: "AB12", Quantity: 0}, validateItem{SKU: "AB12CD34", Quantity: 101})
+	order.Tags = []string{"go", "w"}
+	order.Address.City = ""
+	order.Extra["size"] = "xl"
+	order.Confirm = "secrets"
+	order.EndsAt = order.StartsAt
+	order.MaxPrice = 0.5
+
+	err := ValidateStruct(order)
+	want := ValidationErrors{
+		"id":                "must be a ULID",
+		"email":             "is required",
+		"website":           "must be an absolute URL",
+		"status":            "must be one of open, closed",
+		"priority":          "must be one of 1, 2, 3",
+		"items[1].sku":      "must be exactly 8 characters long",
+		"items[1].quantity": "must be at least 1",
+		"items[2].quantity": "must be at most 100",
+		"tags[1]":           "must be at least 2 characters long",
+		"address.city":      "is required",
+		"extra[size]":       "must be hexadecimal",
+		"confirm":           "must be equal to password",
+		"ends_at":           "must be greater than starts_at",
+		"max_price":         "must be greater than or equal to min_price",
+	}
+	if !reflect.DeepEqual(err, want) {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+
+	order = validOrder()
+	order.Items = nil
+	order.Tags = []string{"a1", "b2", "c3", "d4"}
+	if err := ValidateStruct(&order); !reflect.DeepEqual(err, ValidationErrors{
+		"items": "must contain at least 1 items",
+		"tags":  "must contain at most 3 items",
+	}) {
+		t.Errorf("got %v", err)
+	}
+
+	bounds := struct {
+		Score float64  `json:"score" validate:"gt=0,lte=10"`
+		Name  string   `json:"name" validate:"lt=4"`
+		Tags  []string `json:"tags" validate:"gte=1"`
+		Ports []int    `json:"ports" validate:"dive,gt=1024,lt=65536"`
+	}{Score: 0, Name: "long", Ports: []int{80, 8080}}
+	if err := ValidateStruct(bounds); !reflect.DeepEqual(err, ValidationErrors{
+		"score":    "must be more than 0",
+		"name":     "must be less than 4 characters long",
+		"tags":     "must contain at least 1 items",
+		"ports[0]": "must be more than 1024",
+	}) {
+		t.Errorf("got %v", err)
+	}
+	bounds.Score, bounds.Name, bounds.Tags, bounds.Ports = 10, "abc", []string{"a"}, []int{65535}
+	if err := ValidateStruct(bounds); err != nil {
+		t.Errorf("valid bounds rejected: %v", err)
+	}
+}
+
+func TestValidateStructRuleErrors(t *testing.T) {
+	values := []interface{}{
+		&struct {
+			N int `validate:"email"`
+		}{},
+		&struct {
+			S string `validate:"min=x"`
+		}{},
+		&struct {
+			S string `validate:"eqfield=Missing"`
+		}{},
+		&struct {
+			S string `validate:"unknown"`
+		}{},
+		&struct {
+			S string `validate:"dive"`
+		}{},
+		&struct {
+			A string
+			B string `validate:"gtfield=A"`
+		}{},
+		&struct {
+			Items []struct {
+				N int `validate:"required_with=M"`
+			}
+		}{Items: make([]struct {
+			N int `validate:"required_with=M"`
+		}, 1)},
+	}
+	for _, v := range values {
+		for i := 0; i < 2; i++ {
+			err := ValidateStruct(v)
+			if _, ok := err.(*RuleError); !ok {
+				t.Errorf("%T got %v, want a *RuleError", v, err)
+			}
+		}
+	}
+	err := ValidateStruct(values[3])
+	if !strings.HasPrefix(err.Error(), "literoute: validate rule unknown on field S of ") || !strings.HasSuffix(err.Error(), ": unknown rule") {
+		t.Errorf("got %q", err)
+	}
+}
+
+func TestReadValidation(t *testing.T) {
+	type signup struct {
+		Email string `json:"email" form:"email" url:"email" validate:"required,email"`
+		Age   int    `json:"age" form:"age" url:"age" validate:"min=18"`
+	}
+
+	mux := Default()
+	mux.Post("/json", HandleErr(func(ctx Context) error {
+		var s signup
+		return ctx.ReadJSON(&s)
+	}))
+	mux.Post("/form", HandleErr(func(ctx Context) error {
+		var s signup
+		return ctx.ReadForm(&s)
+	}))
+	mux.Get("/query", HandleErr(func(ctx Context) error {
+		var s signup
+		return ctx.ReadQuery(&s)
+	}))
+
+	want := `{"age":"must be at least 18","email":"must be an email address"}`
+	requests := []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"email":"a","age":12}`)),
+		httptest.NewRequest(http.MethodPost, "/form", strings.NewReader("email=a&age=12")),
+		httptest.NewRequest(http.MethodGet, "/query?email=a&age=12", nil),
+	}
+	requests[1].Header.Set("Content-Type", ContentFormHeaderValue)
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		if rec.Code != DefaultConfig.Status.InvalidRequest || rec.Body.String() != want {
+			t.Errorf("%s got %d %s", req.URL, rec.Code, rec.Body.String())
+		}
+	}
+
+	rec := serveRequest(mux, http.MethodGet, "/query")
+	if body := rec.Body.String(); body != `{"age":"must be at least 18","email":"is required"}` {
+		t.Errorf("empty query got %s", body)
+	}
+
+	mux.Post("/bounds", HandleErr(func(ctx Context) error {
+		var s struct {
+			Age int `json:"age" validate:"gte=0"`
+		}
+		return ctx.ReadJSON(&s)
+	}))
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/bounds", strings.NewReader(`{"age":-1}`)))
+	if rec.Code != DefaultConfig.Status.InvalidRequest || rec.Body.String() != `{"age":"must be at least 0"}` {
+		t.Errorf("gte rule got %d %s", rec.Code, rec.Body.String())
+	}
+
+	type foreign struct {
+		Age  int `json:"age" form:"age" validate:"required_with=Name"`
+		Name string
+	}
+	config := DefaultConfig
+	config.SkipValidation = true
+	skipping := New(config)
+	skipping.Post("/json", HandleErr(func(ctx Context) error {
+		var s foreign
+		return ctx.ReadJSON(&s)
+	}))
+	skipping.Post("/bind", HandleErr(func(ctx Context) error {
+		var s foreign
+		return ctx.Bind(&s)
+	}))
+	requests = []*http.Request{
+		httptest.NewRequest(http.MethodPost, "/json", strings.NewReader(`{"age":1}`)),
+		httptest.NewRequest(http.MethodPost, "/bind", strings.NewReader("age=1")),
+	}
+	requests[1].Header.Set("Content-Type", ContentFormHeaderValue)
+	for _, req := range requests {
+		rec := httptest.NewRecorder()
+		skipping.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Errorf("skipped validation %s got %d %s", req.URL, rec.Code, rec.Body.String())
+		}
+	}
+
+	var readErr error
+	mux.PanicReporter(func(ctx Context, v interface{}, stack []byte) {
+		t.Errorf("unexpected panic %v", v)
+	})
+	mux.Post("/foreign", func(ctx Context) {
+		var s foreign
+		readErr = ctx.ReadJSON(&s)
+		ctx.StatusCode(http.StatusNoContent)
+	})
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/foreign", strings.NewReader(`{"age":1}`)))
+	if _, ok := readErr.(*RuleError); !ok || rec.Code != http.StatusNoContent {
+		t.Errorf("foreign rule got %d %v", rec.Code, readErr)
+	}
+}
@@ -1,16 +1,98 @@
 package literoute
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Validator interface {
 	Validate(string) bool
 	OnFail(Context)
 }
 
+// ParamValidator builds a Validator from the arguments written in the route,
+// e.g. "/:age|Range(18,120)". Arity returns the accepted number of arguments,
+// a negative max means any number above min. Bind is called once when the
+// route is registered.
+type ParamValidator interface {
+	Arity() (min int, max int)
+	Bind(args []string) (Validator, error)
+}
+
+// paramBinder is implemented by validators that need the name of the param
+// they check, a bound copy is kept by the route.
+type paramBinder interface {
+	bindParam(name string) Validator
+}
+
 type validatorInfo struct {
 	start int
 	end   int
 	name  string
 }
 
+type validatorRef struct {
+	name    string
+	args    []string
+	hasArgs bool
+}
+
+func (ref validatorRef) String() string {
+	if ref.hasArgs {
+		return ref.name + "(" + strings.Join(ref.args, ",") + ")"
+	}
+	return ref.name
+}
+
+func parseValidatorRef(s string) validatorRef {
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		return validatorRef{name: s}
+	}
+	if s[len(s)-1] != ')' {
+		panic("literoute: unclosed arguments in validator " + s)
+	}
+	ref := validatorRef{name: s[:open], hasArgs: true}
+	if args := strings.TrimSpace(s[open+1 : len(s)-1]); args != "" {
+		for _, arg := range strings.Split(args, ",") {
+			ref.args = append(ref.args, strings.TrimSpace(arg))
+		}
+	}
+	return ref
+}
+
+func bindValidator(validators map[string]Validator, paramValidators map[string]ParamValidator, ref validatorRef) (Validator, bool) {
+	if pv, has := paramValidators[ref.name]; has {
+		min, max := pv.Arity()
+		if n := len(ref.args); n < min || (max >= 0 && n > max) {
+			panic(fmt.Sprintf("literoute: validator %s takes %s, got %d", ref, arityString(min, max), n))
+		}
+		validator, err := pv.Bind(ref.args)
+		if err != nil {
+			panic(fmt.Sprintf("literoute: validator %s: %v", ref, err))
+		}
+		return validator, true
+	}
+	if validator, has := validators[ref.name]; has {
+		if len(ref.args) > 0 {
+			panic(fmt.Sprintf("literoute: validator %s takes no arguments", ref))
+		}
+		return validator, true
+	}
+	return nil, false
+}
+
+func arityString(min int, max int) string {
+	switch {
+	case max < 0:
+		return fmt.Sprintf("at least %d arguments", min)
+	case min == max:
+		return fmt.Sprintf("%d arguments", min)
+	default:
+		return fmt.Sprintf("%d to %d arguments", min, max)
+	}
+}
+
 func containsValidators(path string) []validatorInfo {
 	var index []int
 	for i, c := range path {
@@ -0,0 +1,185 @@
+package literoute
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ParamError is the body written by the OnFail of the built-in validators.
+type ParamError struct {
+	Param   string `json:"param" xml:"param"`
+	Rule    string `json:"rule" xml:"rule"`
+	Message string `json:"message" xml:"message"`
+}
+
+func (e ParamError) Error() string {
+	return fmt.Sprintf("param %s %s", e.Param, e.Message)
+}
+
+var (
+	uuidRegex     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	ulidRegex     = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Za-hjkmnp-tv-z]{25}$`)
+	alphaRegex    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphaNumRegex = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	hexRegex      = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+)
+
+// RegisterBuiltinValidators registers the standard validators, Default
+// registers them already:
+// Int, Uint, Float, Bool, UUID, ULID, Alpha, AlphaNum, Hex, Base64URL, Email,
+// Date, Enum(a,b,...), Range(min,max) and Len(n) or Len(min,max).
+func (m *LiteMux) RegisterBuiltinValidators() {
+	m.RegisterValidator("Int", newRuleValidator("Int", "must be an integer", func(s string) bool {
+		_, err := strconv.ParseInt(s, 10, 64)
+		return err == nil
+	}))
+	m.RegisterValidator("Uint", newRuleValidator("Uint", "must be an unsigned integer", func(s string) bool {
+		_, err := strconv.ParseUint(s, 10, 64)
+		return err == nil
+	}))
+	m.RegisterValidator("Float", newRuleValidator("Float", "must be a number", func(s string) bool {
+		_, err := strconv.ParseFloat(s, 64)
+		return err == nil
+	}))
+	m.RegisterValidator("Bool", newRuleValidator("Bool", "must be a boolean", func(s string) bool {
+		_, err := strconv.ParseBool(s)
+		return err == nil
+	}))
+	m.RegisterValidator("UUID", newRuleValidator("UUID", "must be a UUID", uuidRegex.MatchString))
+	m.RegisterValidator("ULID", newRuleValidator("ULID", "must be a ULID", ulidRegex.MatchString))
+	m.RegisterValidator("Alpha", newRuleValidator("Alpha", "must contain only letters", alphaRegex.MatchString))
+	m.RegisterValidator("AlphaNum", newRuleValidator("AlphaNum", "must contain only letters and digits", alphaNumRegex.MatchString))
+	m.RegisterValidator("Hex", newRuleValidator("Hex", "must be hexadecimal", hexRegex.MatchString))
+	m.RegisterValidator("Base64URL", newRuleValidator("Base64URL", "must be base64url encoded", func(s string) bool {
+		if s == "" {
+			return false
+		}
+		_, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		return err == nil
+	}))
+	m.RegisterValidator("Email", newRuleValidator("Email", "must be an email address", isEmail))
+	m.RegisterValidator("Date", newRuleValidator("Date", "must be a date formatted as YYYY-MM-DD", func(s string) bool {
+		_, err := time.Parse("2006-01-02", s)
+		return err == nil
+	}))
+	m.RegisterParamValidator("Enum", &ruleParamValidator{min: 1, max: -1, bind: bindEnum})
+	m.RegisterParamValidator("Range", &ruleParamValidator{min: 2, max: 2, bind: bindRange})
+	m.RegisterParamValidator("Len", &ruleParamValidator{min: 1, max: 2, bind: bindLen})
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+type ruleValidator struct {
+	rule    string
+	message string
+	param   string
+	check   func(string) bool
+}
+
+func newRuleValidator(rule string, message string, check func(string) bool) *ruleValidator {
+	return &ruleValidator{rule: rule, message: message, check: check}
+}
+
+func (v *ruleValidator) Validate(param string) bool {
+	if strings.IndexByte(param, '%') >= 0 {
+		param, _ = url.PathUnescape(param)
+	}
+	return v.check(param)
+}
+
+func (v *ruleValidator) OnFail(ctx Context) {
+	ctx.Invalid(ParamError{
+		Param:   v.param,
+		Rule:    v.rule,
+		Message: v.message,
+	})
+}
+
+func (v *ruleValidator) bindParam(name string) Validator {
+	bound := *v
+	bound.param = name
+	return &bound
+}
+
+type ruleParamValidator struct {
+	min  int
+	max  int
+	bind func(args []string) (*ruleValidator, error)
+}
+
+func (v *ruleParamValidator) Arity() (int, int) {
+	return v.min, v.max
+}
+
+func (v *ruleParamValidator) Bind(args []string) (Validator, error) {
+	validator, err := v.bind(args)
+	if err != nil {
+		return nil, err
+	}
+	return validator, nil
+}
+
+func bindEnum(args []string) (*ruleValidator, error) {
+	rule := "Enum(" + strings.Join(args, ",") + ")"
+	message := "must be one of " + strings.Join(args, ", ")
+	return newRuleValidator(rule, message, func(s string) bool {
+		for _, arg := range args {
+			if s == arg {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+func bindRange(args []string) (*ruleValidator, error) {
+	min, err := strconv.ParseFloat(args[0], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid min %q", args[0])
+	}
+	max, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid max %q", args[1])
+	}
+	if min > max {
+		return nil, fmt.Errorf("min %s is greater than max %s", args[0], args[1])
+	}
+	rule := "Range(" + args[0] + "," + args[1] + ")"
+	message := "must be between " + args[0] + " and " + args[1]
+	return newRuleValidator(rule, message, func(s string) bool {
+		n, err := strconv.ParseFloat(s, 64)
+		return err == nil && n >= min && n <= max
+	}), nil
+}
+
+func bindLen(args []string) (*ruleValidator, error) {
+	min, err := strconv.Atoi(args[0])
+	if err != nil || min < 0 {
+		return nil, fmt.Errorf("invalid length %q", args[0])
+	}
+	max := min
+	if len(args) == 2 {
+		if max, err = strconv.Atoi(args[1]); err != nil || max < min {
+			return nil, fmt.Errorf("invalid max length %q", args[1])
+		}
+	}
+	rule := "Len(" + strings.Join(args, ",") + ")"
+	message := "must be " + args[0] + " characters long"
+	if min != max {
+		message = "must be between " + args[0] + " and " + args[1] + " characters long"
+	}
+	return newRuleValidator(rule, message, func(s string) bool {
+		n := utf8.RuneCountInString(s)
+		return n >= min && n <= max
+	}), nil
+}
@@ -0,0 +1,130 @@
+package literoute
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+)
+
+type testRange struct{}
+
+func (v *testRange) Arity() (int, int) {
+	return 2, 2
+}
+
+func (v *testRange) Bind(args []string) (Validator, error) {
+	min, err := strconv.Atoi(args[0])
+	if err != nil {
+		return nil, err
+	}
+	max, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, err
+	}
+	return &testRangeValidator{min: min, max: max}, nil
+}
+
+type testRangeValidator struct {
+	min int
+	max int
+}
+
+func (v *testRangeValidator) Validate(param string) bool {
+	n, err := strconv.Atoi(param)
+	return err == nil && n >= v.min && n <= v.max
+}
+
+func (v *testRangeValidator) OnFail(ctx Context) {
+	ctx.Invalid(map[string]string{"msg": "out of range"})
+}
+
+func TestParamValidator(t *testing.T) {
+	mux := Default()
+	mux.RegisterParamValidator("Range", &testRange{})
+	mux.Get("/age/:age|Range(18,120)", writeName("adult"))
+	mux.Get("/age/:age|Range(0, 17)", writeName("minor"))
+
+	cases := []struct {
+		path string
+		want string
+		code int
+	}{
+		{"/age/30", "adult", http.StatusOK},
+		{"/age/12", "minor", http.StatusOK},
+		{"/age/200", `{"msg":"out of range"}`, DefaultConfig.Status.InvalidRequest},
+	}
+	for _, c := range cases {
+		rec := serveRequest(mux, http.MethodGet, c.path)
+		if rec.Code != c.code || rec.Body.String() != c.want {
+			t.Errorf("GET %s got %d %q, want %d %q", c.path, rec.Code, rec.Body.String(), c.code, c.want)
+		}
+	}
+}
+
+func TestParamValidatorRegistrationPanics(t *testing.T) {
+	paths := []string{
+		"/a/:v|Range(1)",
+		"/a/:v|Range(a,b)",
+		"/a/:v|IsInt(1)",
+		"/a/:v|Missing",
+	}
+	for _, path := range paths {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("registering %s did not panic", path)
+				}
+			}()
+			mux := Default()
+			mux.RegisterValidator("IsInt", &IsIntValidator{})
+			mux.RegisterParamValidator("Range", &testRange{})
+			mux.Get(path, writeName("a"))
+		}()
+	}
+}
+
+func TestBuiltinValidators(t *testing.T) {
+	cases := []struct {
+		rule    string
+		valid   []string
+		invalid []string
+	}{
+		{"Int", []string{"42", "-7", "+5"}, []string{"4.2", "x"}},
+		{"Uint", []string{"42"}, []string{"-7"}},
+		{"Float", []string{"4.2", "-1", "+1.5"}, []string{"x"}},
+		{"Bool", []string{"true", "0"}, []string{"yes"}},
+		{"UUID", []string{"123e4567-e89b-12d3-a456-426614174000"}, []string{"123e4567"}},
+		{"ULID", []string{"01ARZ3NDEKTSV4RRFFQ69G5FAV"}, []string{"01ARZ3NDEKTSV4RRFFQ69G5FAU1", "81ARZ3NDEKTSV4RRFFQ69G5FAV"}},
+		{"Alpha", []string{"abc"}, []string{"ab1"}},
+		{"AlphaNum", []string{"ab1"}, []string{"ab-1"}},
+		{"Hex", []string{"0aF9"}, []string{"0g"}},
+		{"Base64URL", []string{"aGVsbG8_", "aGk="}, []string{"a+b"}},
+		{"Email", []string{"a@example.com"}, []string{"a.example.com"}},
+		{"Date", []string{"2020-02-29"}, []string{"2021-02-29", "2020-1-1"}},
+		{"Enum(red,green)", []string{"red", "green"}, []string{"blue"}},
+		{"Range(18,120)", []string{"18", "120", "30.5", "+30"}, []string{"17", "121"}},
+		{"Len(3)", []string{"abc"}, []string{"ab", "abcd"}},
+		{"Len(2,4)", []string{"ab", "abcd"}, []string{"a", "abcde"}},
+	}
+
+	for _, c := range cases {
+		mux := Default()
+		mux.Get("/v/:value|"+c.rule, writeName("ok"))
+		for _, value := range c.valid {
+			if rec := serveRequest(mux, http.MethodGet, "/v/"+value); rec.Code != http.StatusOK {
+				t.Errorf("%s rejected %q with status %d", c.rule, value, rec.Code)
+			}
+		}
+		for _, value := range c.invalid {
+			rec := serveRequest(mux, http.MethodGet, "/v/"+value)
+			if rec.Code != DefaultConfig.Status.InvalidRequest {
+				t.Errorf("%s accepted %q with status %d", c.rule, value, rec.Code)
+				continue
+			}
+			want := `{"param":"value","rule":"` + c.rule + `"`
+			if body := rec.Body.String(); len(body) < len(want) || body[:len(want)] != want {
+				t.Errorf("%s wrote body %s", c.rule, body)
+			}
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package literoute
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Values holds request scoped data shared by middleware and handlers, it is
+// cleared when the context is released.
+type Values struct {
+	entries []valueEntry
+}
+
+type valueEntry struct {
+	key   string
+	value interface{}
+}
+
+func (v *Values) Set(key string, value interface{}) {
+	for i := range v.entries {
+		if v.entries[i].key == key {
+			v.entries[i].value = value
+			return
+		}
+	}
+	v.entries = append(v.entries, valueEntry{key: key, value: value})
+}
+
+func (v *Values) Get(key string) interface{} {
+	for i := range v.entries {
+		if v.entries[i].key == key {
+			return v.entries[i].value
+		}
+	}
+	return nil
+}
+
+func (v *Values) Exists(key string) bool {
+	for i := range v.entries {
+		if v.entries[i].key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// GetString returns the value of key when it is a string or a fmt.Stringer.
+func (v *Values) GetString(key string) string {
+	switch value := v.Get(key).(type) {
+	case string:
+		return value
+	case fmt.Stringer:
+		return value.String()
+	}
+	return ""
+}
+
+// GetInt returns the value of key when it is an int or a string holding
+// one, ErrNotFound when key is not set.
+func (v *Values) GetInt(key string) (int, error) {
+	switch value := v.Get(key).(type) {
+	case nil:
+		return -1, ErrNotFound
+	case int:
+		return value, nil
+	case string:
+		return strconv.Atoi(value)
+	default:
+		return -1, fmt.Errorf("value %s is %T, not int", key, value)
+	}
+}
+
+func (v *Values) Delete(key string) {
+	for i := range v.entries {
+		if v.entries[i].key == key {
+			last := len(v.entries) - 1
+			v.entries[i] = v.entries[last]
+			v.entries[last] = valueEntry{}
+			v.entries = v.entries[:last]
+			return
+		}
+	}
+}
+
+func (v *Values) Len() int {
+	return len(v.entries)
+}
+
+func (v *Values) Visit(visitor func(key string, value interface{})) {
+	for _, entry := range v.entries {
+		visitor(entry.key, entry.value)
+	}
+}
+
+// Reset removes all the values, the storage is kept for the next request.
+func (v *Values) Reset() {
+	for i := range v.entries {
+		v.entries[i] = valueEntry{}
+	}
+	v.entries = v.entries[:0]
+}
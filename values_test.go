@@ -0,0 +1,69 @@
+package literoute
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	var v Values
+	v.Set("user", "ann")
+	v.Set("id", 7)
+	v.Set("page", "3")
+	v.Set("user", "bob")
+
+	if got := v.GetString("user"); got != "bob" {
+		t.Errorf("user is %q", got)
+	}
+	if n, err := v.GetInt("id"); n != 7 || err != nil {
+		t.Errorf("id is %d, %v", n, err)
+	}
+	if n, err := v.GetInt("page"); n != 3 || err != nil {
+		t.Errorf("page is %d, %v", n, err)
+	}
+	if _, err := v.GetInt("user"); err == nil {
+		t.Errorf("user read as int")
+	}
+	if _, err := v.GetInt("missing"); err != ErrNotFound {
+		t.Errorf("missing key got %v", err)
+	}
+
+	v.Delete("id")
+	if v.Exists("id") || v.Get("id") != nil || v.Len() != 2 {
+		t.Errorf("id was not deleted")
+	}
+	visited := map[string]interface{}{}
+	v.Visit(func(key string, value interface{}) {
+		visited[key] = value
+	})
+	if len(visited) != 2 || visited["user"] != "bob" || visited["page"] != "3" {
+		t.Errorf("visited %v", visited)
+	}
+}
+
+func TestContextValues(t *testing.T) {
+	mux := Default()
+	var used *Values
+	mux.AppendMiddlewareFunc(func(ctx Context, next HandleFunc) {
+		ctx.Values().Set("user", ctx.GetHeader("X-User"))
+		next(ctx)
+		used = ctx.Values()
+	})
+	mux.Get("/:id", func(ctx Context) {
+		_, _ = ctx.WriteString(ctx.Values().GetString("user") + " " + ctx.Param("id"))
+	})
+
+	for _, user := range []string{"ann", ""} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/7", nil)
+		req.Header.Set("X-User", user)
+		mux.ServeHTTP(rec, req)
+		if want := user + " 7"; rec.Body.String() != want {
+			t.Errorf("got %q, want %q", rec.Body.String(), want)
+		}
+		if used.Len() != 0 {
+			t.Errorf("values kept after the request was served")
+		}
+	}
+}